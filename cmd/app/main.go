@@ -6,20 +6,33 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"gogogo/config"
+	"gogogo/internal/actor"
 	"gogogo/internal/good"
+	"gogogo/internal/idempotency"
+	"gogogo/internal/ingest"
+	"gogogo/internal/outbox"
+	"gogogo/internal/project"
 	"gogogo/pkg/brokers/nats"
+	"gogogo/pkg/cache"
+	"gogogo/pkg/cache/memory"
 	"gogogo/pkg/cache/redis"
 	"gogogo/pkg/store/postgres"
 
 	"github.com/go-chi/chi/v5"
-	redix "github.com/gomodule/redigo/redis"
 )
 
 func main() {
 	cfg := config.New()
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// postgres
 	store, err := postgres.New(cfg)
 	if err != nil {
@@ -27,19 +40,33 @@ func main() {
 	}
 	defer store.Pool.Close()
 
-	// redis
-	r := redis.New(cfg)
-	redisConn := r.Pool.Get()
-	err = store.Pool.Ping(context.Background())
-	if err != nil {
-		log.Fatalf("cant ping redis: %v", err)
-	}
-	defer func(redisConn redix.Conn) {
-		err := redisConn.Close()
+	// cache
+	var c, idempotencyCache cache.Cache
+	switch cfg.CacheDriver {
+	case "memory":
+		c = memory.New(cfg.CacheMemoryCapacity)
+		// kept apart, so listings churning through the LRU can't evict stored
+		// responses
+		idempotencyCache = memory.New(cfg.IdempotencyMemoryCapacity)
+	case "redis":
+		r := redis.New(cfg)
+		pingCtx, cancel := context.WithTimeout(ctx, cfg.RedisDialTimeout)
+		err = r.Ping(pingCtx)
+		cancel()
 		if err != nil {
-			log.Printf("cant close redis conn: %v", err)
+			log.Fatalf("cant ping redis: %v", err)
 		}
-	}(redisConn)
+		defer func() {
+			err := r.Pool.Close()
+			if err != nil {
+				log.Printf("cant close redis pool: %v", err)
+			}
+		}()
+		c = r
+		idempotencyCache = r
+	default:
+		log.Fatalf("unknown cache driver: %s", cfg.CacheDriver)
+	}
 
 	// nats
 	n, err := nats.New(cfg)
@@ -48,24 +75,111 @@ func main() {
 	}
 	defer n.Conn.Close()
 
-	goodStore := good.NewStore(store.Pool, n.Conn)
-	goodService := good.NewService(goodStore, redisConn)
+	relay := outbox.NewRelay(store.Pool, n, outbox.Config{
+		PollInterval:   cfg.OutboxPollInterval,
+		BatchSize:      cfg.OutboxBatchSize,
+		MaxBackoff:     cfg.OutboxMaxBackoff,
+		PublishTimeout: cfg.OutboxPublishTimeout,
+		Retention:      cfg.OutboxRetention,
+		PruneInterval:  cfg.OutboxPruneInterval,
+	})
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(ctx)
+	}()
+
+	sub, err := n.Subscribe(cfg)
+	if err != nil {
+		log.Fatalf("cant subscribe to nats: %v", err)
+	}
+
+	ingester := ingest.NewIngester(sub, ingest.NewClickHouseSink(
+		cfg.ClickHouseURL,
+		"logs",
+		&http.Client{Timeout: cfg.ClickHouseTimeout},
+	), ingest.Config{
+		BatchSize:    cfg.IngestBatchSize,
+		FetchTimeout: cfg.IngestFetchTimeout,
+		RetryDelay:   cfg.IngestRetryDelay,
+	})
+	ingestDone := make(chan struct{})
+	go func() {
+		defer close(ingestDone)
+		ingester.Run(ctx)
+	}()
+
+	goodStore := good.NewStore(store.Pool)
+	auditReader := good.NewClickHouseAuditReader(cfg.ClickHouseURL, &http.Client{Timeout: cfg.ClickHouseTimeout})
+	goodService := good.NewService(goodStore, c, auditReader)
+
+	purgeDone := make(chan struct{})
+	if cfg.GoodsRetentionDays > 0 {
+		purger := good.NewPurger(goodService, good.PurgeConfig{
+			Retention: time.Duration(cfg.GoodsRetentionDays) * 24 * time.Hour,
+			Interval:  cfg.GoodsPurgeInterval,
+			BatchSize: cfg.GoodsPurgeBatchSize,
+		})
+		go func() {
+			defer close(purgeDone)
+			purger.Run(ctx)
+		}()
+	} else {
+		close(purgeDone)
+	}
+
+	projectStore := project.NewStore(store.Pool)
+	projectService := project.NewService(projectStore)
 
 	router := chi.NewRouter()
+	router.Use(actor.Middleware)
+	router.Use(idempotency.Middleware(idempotencyCache, idempotency.Config{
+		TTL:          cfg.IdempotencyTTL,
+		LockTTL:      cfg.IdempotencyLockTTL,
+		CacheTimeout: good.CacheOperationTimeout,
+	}))
+
 	router.Get("/goods/list", good.ListGoods(goodService))
+	router.Get("/good", good.GetGood(goodService))
 	router.Post("/good/create", good.CreateGood(goodService))
 	router.Delete("/good/delete", good.DeleteGood(goodService))
+	router.Post("/good/restore", good.RestoreGood(goodService))
 	router.Patch("/good/update", good.UpdateGood(goodService))
 	router.Patch("/good/reprioritize", good.ReprioritizeGood(goodService))
+	router.Post("/goods/batch", good.CreateGoods(goodService))
+	router.Patch("/goods/batch", good.UpdateGoods(goodService))
+	router.Delete("/goods/batch", good.DeleteGoods(goodService))
+	router.Get("/good/history", good.GoodHistory(goodService))
+	router.Get("/goods/history", good.ProjectHistory(goodService))
+
+	router.Get("/projects/list", project.ListProjects(projectService))
+	router.Get("/project", project.GetProject(projectService))
+	router.Post("/project/create", project.CreateProject(projectService))
+	router.Patch("/project/rename", project.RenameProject(projectService))
+	router.Patch("/project/archive", project.ArchiveProject(projectService))
 
 	server := http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
 		Handler: router,
 	}
 
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("server shutdown error: %v", err)
+		}
+	}()
+
 	fmt.Println("starting server", server.Addr)
 	err = server.ListenAndServe()
 	if err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Printf("server error: %v\n", err)
 	}
+
+	stop()
+	<-relayDone
+	<-ingestDone
+	<-purgeDone
 }
@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"log"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -23,6 +26,116 @@ type Config struct {
 	PostgresHost,
 	PostgresPort,
 	PostgresDb string
+
+	// CacheDriver selects the cache backend, either "redis" or "memory".
+	CacheDriver         string
+	CacheMemoryCapacity int
+
+	RedisPassword string
+	RedisDB,
+	RedisMaxIdle,
+	RedisMaxActive int
+	RedisWait bool
+	RedisIdleTimeout,
+	RedisDialTimeout,
+	RedisReadTimeout,
+	RedisWriteTimeout time.Duration
+
+	ClickHouseURL     string
+	ClickHouseTimeout time.Duration
+
+	NatsStream         string
+	NatsStreamSubjects []string
+	NatsStreamStorage  string
+	NatsStreamReplicas int
+	NatsStreamMaxBytes int64
+	NatsStreamMaxAge,
+	NatsStreamDuplicates time.Duration
+	// NatsConsumer names the durable consumer ingesting the stream into
+	// ClickHouse.
+	NatsConsumer        string
+	NatsConsumerAckWait time.Duration
+
+	IngestBatchSize int
+	IngestFetchTimeout,
+	IngestRetryDelay time.Duration
+
+	OutboxBatchSize int
+	OutboxPollInterval,
+	OutboxMaxBackoff,
+	OutboxPublishTimeout,
+	// OutboxRetention is how long delivered outbox messages are kept, 0 keeps
+	// them forever.
+	OutboxRetention,
+	OutboxPruneInterval time.Duration
+
+	IdempotencyTTL,
+	IdempotencyLockTTL time.Duration
+	// IdempotencyMemoryCapacity sizes the in-memory cache of stored responses,
+	// which is separate from the listings cache.
+	IdempotencyMemoryCapacity int
+
+	// GoodsRetentionDays is how long removed goods are kept, 0 keeps them forever.
+	GoodsRetentionDays  int
+	GoodsPurgeInterval  time.Duration
+	GoodsPurgeBatchSize int
+}
+
+func getInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("invalid %s value %q, using %d", key, value, fallback)
+		return fallback
+	}
+
+	return parsed
+}
+
+// getPositiveInt is getInt for settings that must be at least 1, such as batch
+// sizes.
+func getPositiveInt(key string, fallback int) int {
+	value := getInt(key, fallback)
+	if value < 1 {
+		log.Printf("invalid %s value %d, must be positive, using %d", key, value, fallback)
+		return fallback
+	}
+
+	return value
+}
+
+func getBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("invalid %s value %q, using %t", key, value, fallback)
+		return fallback
+	}
+
+	return parsed
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("invalid %s value %q, using %s", key, value, fallback)
+		return fallback
+	}
+
+	return parsed
 }
 
 func New() *Config {
@@ -70,9 +183,70 @@ func New() *Config {
 		os.Getenv("NATS_URL"),
 	)
 
+	if cfg.ClickHouseURL = os.Getenv("CLICKHOUSE_URL"); cfg.ClickHouseURL == "" {
+		cfg.ClickHouseURL = "http://localhost:8123"
+	}
+	cfg.ClickHouseTimeout = getDuration("CLICKHOUSE_TIMEOUT", 10*time.Second)
+
+	if cfg.NatsStream = os.Getenv("NATS_STREAM"); cfg.NatsStream == "" {
+		cfg.NatsStream = "LOGS"
+	}
+
+	cfg.NatsStreamSubjects = []string{"logs"}
+	if subjects := os.Getenv("NATS_SUBJECTS"); subjects != "" {
+		cfg.NatsStreamSubjects = strings.Split(subjects, ",")
+	}
+
+	if cfg.NatsStreamStorage = os.Getenv("NATS_STREAM_STORAGE"); cfg.NatsStreamStorage == "" {
+		cfg.NatsStreamStorage = "file"
+	}
+	cfg.NatsStreamReplicas = getInt("NATS_STREAM_REPLICAS", 1)
+	cfg.NatsStreamMaxBytes = int64(getInt("NATS_STREAM_MAX_BYTES", -1))
+	cfg.NatsStreamMaxAge = getDuration("NATS_STREAM_MAX_AGE", 7*24*time.Hour)
+	cfg.NatsStreamDuplicates = getDuration("NATS_STREAM_DUPLICATES", 2*time.Minute)
+
+	if cfg.NatsConsumer = os.Getenv("NATS_CONSUMER"); cfg.NatsConsumer == "" {
+		cfg.NatsConsumer = "clickhouse"
+	}
+	cfg.NatsConsumerAckWait = getDuration("NATS_CONSUMER_ACK_WAIT", 30*time.Second)
+
+	cfg.IngestBatchSize = getPositiveInt("INGEST_BATCH_SIZE", 100)
+	cfg.IngestFetchTimeout = getDuration("INGEST_FETCH_TIMEOUT", 5*time.Second)
+	cfg.IngestRetryDelay = getDuration("INGEST_RETRY_DELAY", 5*time.Second)
+
 	if cfg.RedisURL = os.Getenv("REDIS_URL"); cfg.RedisURL == "" {
 		cfg.RedisURL = "0.0.0.0:6379"
 	}
 
+	if cfg.CacheDriver = os.Getenv("CACHE_DRIVER"); cfg.CacheDriver == "" {
+		cfg.CacheDriver = "redis"
+	}
+	cfg.CacheMemoryCapacity = getInt("CACHE_MEMORY_CAPACITY", 10000)
+
+	cfg.RedisPassword = os.Getenv("REDIS_PASSWORD")
+	cfg.RedisDB = getInt("REDIS_DB", 0)
+	cfg.RedisMaxIdle = getInt("REDIS_MAX_IDLE", 10)
+	cfg.RedisMaxActive = getInt("REDIS_MAX_ACTIVE", 50)
+	cfg.RedisWait = getBool("REDIS_WAIT", true)
+	cfg.RedisIdleTimeout = getDuration("REDIS_IDLE_TIMEOUT", 240*time.Second)
+	cfg.RedisDialTimeout = getDuration("REDIS_DIAL_TIMEOUT", 5*time.Second)
+	cfg.RedisReadTimeout = getDuration("REDIS_READ_TIMEOUT", 3*time.Second)
+	cfg.RedisWriteTimeout = getDuration("REDIS_WRITE_TIMEOUT", 3*time.Second)
+
+	cfg.OutboxBatchSize = getPositiveInt("OUTBOX_BATCH_SIZE", 100)
+	cfg.OutboxPollInterval = getDuration("OUTBOX_POLL_INTERVAL", time.Second)
+	cfg.OutboxMaxBackoff = getDuration("OUTBOX_MAX_BACKOFF", 5*time.Minute)
+	cfg.OutboxPublishTimeout = getDuration("OUTBOX_PUBLISH_TIMEOUT", 5*time.Second)
+	cfg.OutboxRetention = getDuration("OUTBOX_RETENTION", 24*time.Hour)
+	cfg.OutboxPruneInterval = getDuration("OUTBOX_PRUNE_INTERVAL", time.Hour)
+
+	cfg.IdempotencyTTL = getDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	cfg.IdempotencyLockTTL = getDuration("IDEMPOTENCY_LOCK_TTL", time.Minute)
+	cfg.IdempotencyMemoryCapacity = getInt("IDEMPOTENCY_MEMORY_CAPACITY", 10000)
+
+	cfg.GoodsRetentionDays = getInt("GOODS_RETENTION_DAYS", 0)
+	cfg.GoodsPurgeInterval = getDuration("GOODS_PURGE_INTERVAL", time.Hour)
+	cfg.GoodsPurgeBatchSize = getPositiveInt("GOODS_PURGE_BATCH_SIZE", 100)
+
 	return &cfg
 }
@@ -0,0 +1,64 @@
+-- +goose Up
+-- events are ingested by the application through a durable JetStream consumer,
+-- the NATS engine subscribes to core NATS and misses events published while
+-- ClickHouse is down
+-- +goose StatementBegin
+DROP VIEW IF EXISTS logs_to_main;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS nats_logs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+RENAME TABLE logs TO logs_v1;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE logs
+(
+    EventId UUID,
+    Version UInt8,
+    Operation LowCardinality(String),
+    EventTime DateTime64(3, 'UTC'),
+    Actor String,
+    Id Int32,
+    ProjectId Int32,
+    Before String,
+    After String
+) ENGINE = ReplacingMergeTree()
+ORDER BY (ProjectId, Id, EventTime, EventId);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS logs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+RENAME TABLE logs_v1 TO logs;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE nats_logs
+(
+    Id Int32,
+    ProjectId Int32,
+    Name String,
+    Description String,
+    Priority Int32,
+    Removed UInt8,
+    EventTime DateTime
+) ENGINE = NATS SETTINGS
+-- +goose ENVSUB ON
+    nats_url = 'gogogo-nats-1:4222',
+	nats_subjects = 'logs',
+-- +goose ENVSUB OFF
+	nats_format = 'JSONEachRow',
+    nats_max_block_size = 5,
+    nats_flush_interval_ms = 1000;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE MATERIALIZED VIEW logs_to_main TO logs AS SELECT * FROM nats_logs;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE goods ADD COLUMN removed_at TIMESTAMP;
+
+-- the removal time of already removed goods is unknown, start their retention now
+UPDATE goods SET removed_at = current_timestamp WHERE removed;
+
+CREATE INDEX idx_goods_removed_at ON goods (removed_at) WHERE removed;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_goods_removed_at;
+ALTER TABLE goods DROP COLUMN removed_at;
+-- +goose StatementEnd
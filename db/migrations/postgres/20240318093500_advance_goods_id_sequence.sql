@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+-- ids used to be supplied by clients without advancing the sequence
+SELECT setval(pg_get_serial_sequence('goods', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM goods;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the sequence only moved past ids already taken, there is nothing to undo
+SELECT 1;
+-- +goose StatementEnd
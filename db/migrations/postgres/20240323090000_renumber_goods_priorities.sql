@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- priorities used to come from a single sequence across projects and removed
+-- goods used to keep their place, number the active goods of each project 1..n
+UPDATE goods
+SET priority = ranked.priority, version = goods.version + 1
+FROM (
+    SELECT id, ROW_NUMBER() OVER (PARTITION BY project_id ORDER BY priority, id) AS priority
+    FROM goods
+    WHERE NOT removed
+) AS ranked
+WHERE goods.id = ranked.id AND goods.priority IS DISTINCT FROM ranked.priority;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- the previous priorities are not kept, dense ones are valid under either scheme
+SELECT 1;
+-- +goose StatementEnd
@@ -0,0 +1,44 @@
+// Package actor carries the identity of whoever triggered a change through
+// the request context, so it can be recorded in audit events.
+package actor
+
+import (
+	"context"
+	"net/http"
+)
+
+// Header is the request header clients identify themselves with.
+const Header = "X-Actor"
+
+const (
+	Anonymous = "anonymous"
+	System    = "system"
+)
+
+type contextKey struct{}
+
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, contextKey{}, actor)
+}
+
+// FromContext returns the actor stored in ctx, changes made outside of a
+// request are attributed to System.
+func FromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(contextKey{}).(string); ok && actor != "" {
+		return actor
+	}
+
+	return System
+}
+
+// Middleware stores the actor named by the Header in the request context.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor := r.Header.Get(Header)
+		if actor == "" {
+			actor = Anonymous
+		}
+
+		next.ServeHTTP(w, r.WithContext(WithActor(r.Context(), actor)))
+	})
+}
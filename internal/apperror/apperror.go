@@ -0,0 +1,146 @@
+package apperror
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+const InternalErrorMessage = "errors.internal"
+const BadRequestErrorMessage = "errors.badRequest"
+
+// Kind classifies domain errors, each kind maps to a single HTTP status.
+type Kind int
+
+const (
+	KindBadRequest Kind = iota + 1
+	KindNotFound
+	KindConflict
+	KindValidation
+	KindPreconditionFailed
+)
+
+func (k Kind) Status() int {
+	switch k {
+	case KindBadRequest:
+		return http.StatusBadRequest
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindValidation:
+		return http.StatusUnprocessableEntity
+	case KindPreconditionFailed:
+		return http.StatusPreconditionFailed
+	}
+
+	return http.StatusInternalServerError
+}
+
+// Error is a domain error carrying a stable code and message for clients.
+// Errors with the same kind and code match each other with errors.Is, so
+// package level sentinels keep matching after WithDetails or Wrap.
+type Error struct {
+	Kind    Kind
+	Code    int64
+	Message string
+	Details map[string]string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// WithDetails returns a copy of e with the given per-field details.
+func (e *Error) WithDetails(details map[string]string) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+func New(kind Kind, code int64, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func BadRequest(code int64, message string) *Error {
+	return New(KindBadRequest, code, message)
+}
+
+func NotFound(code int64, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code int64, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Validation(code int64, message string) *Error {
+	return New(KindValidation, code, message)
+}
+
+func PreconditionFailed(code int64, message string) *Error {
+	return New(KindPreconditionFailed, code, message)
+}
+
+type ErrorResponse struct {
+	Code    int64             `json:"code"`
+	Message string            `json:"message"`
+	Details map[string]string `json:"details"`
+}
+
+// Response builds the envelope and HTTP status for err. Errors that are not
+// domain errors are logged and reported as an internal error without leaking
+// their text.
+func Response(err error) (ErrorResponse, int) {
+	response := ErrorResponse{
+		Code:    InternalErrorCode,
+		Message: InternalErrorMessage,
+		Details: make(map[string]string),
+	}
+	status := http.StatusInternalServerError
+
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		response.Code = appErr.Code
+		response.Message = appErr.Message
+		if appErr.Details != nil {
+			response.Details = appErr.Details
+		}
+		status = appErr.Kind.Status()
+	} else {
+		log.Printf("%v", err)
+	}
+
+	return response, status
+}
+
+// Write responds with the envelope for err.
+func Write(w http.ResponseWriter, err error) {
+	response, status := Response(err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,19 @@
+package apperror
+
+// Error codes are unique across the API, so clients and errors.Is can tell
+// errors apart by code alone. Packages alias their codes from here, new codes
+// are appended rather than reusing a retired value.
+
+const InternalErrorCode = 1
+const BadRequestErrorCode = 2
+const GoodNotFoundErrorCode = 3
+const GoodProjectNotFoundErrorCode = 4
+const GoodAlreadyExistsErrorCode = 5
+const GoodValidationErrorCode = 6
+const GoodPreconditionFailedErrorCode = 7
+const GoodNotRemovedErrorCode = 8
+const ProjectNotFoundErrorCode = 9
+const ProjectValidationErrorCode = 10
+const IdempotencyKeyReusedErrorCode = 11
+const IdempotencyInProgressErrorCode = 12
+const GoodProjectArchivedErrorCode = 13
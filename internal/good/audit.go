@@ -0,0 +1,180 @@
+package good
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditReader reads back the change events emitted for goods.
+type AuditReader interface {
+	History(ctx context.Context, params HistoryParams) ([]Event, error)
+}
+
+// ClickHouseAuditReader reads events from the ClickHouse logs table over the
+// HTTP interface.
+type ClickHouseAuditReader struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewClickHouseAuditReader(url string, client *http.Client) ClickHouseAuditReader {
+	return ClickHouseAuditReader{
+		URL:    url,
+		Client: client,
+	}
+}
+
+func (r ClickHouseAuditReader) History(ctx context.Context, params HistoryParams) ([]Event, error) {
+	query := `SELECT EventId, Version, Operation, EventTime, Actor, Id, ProjectId, Before, After
+	FROM logs FINAL
+	WHERE ProjectId = {projectId:Int32}`
+	values := url.Values{}
+	values.Set("param_projectId", strconv.FormatInt(params.ProjectId, 10))
+	if params.GoodId != 0 {
+		query += ` AND Id = {id:Int32}`
+		values.Set("param_id", strconv.FormatInt(params.GoodId, 10))
+	}
+	query += `
+	ORDER BY EventTime, EventId
+	LIMIT {limit:UInt32} OFFSET {offset:UInt32}
+	FORMAT JSONEachRow`
+	values.Set("param_limit", strconv.FormatInt(params.Limit, 10))
+	values.Set("param_offset", strconv.FormatInt(params.Offset, 10))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL+"/?"+values.Encode(), strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clickhouse: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return nil, fmt.Errorf("clickhouse responded %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var events []Event
+	scanner := bufio.NewScanner(res.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var row eventRow
+		if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+			return nil, fmt.Errorf("failed to decode event: %w", err)
+		}
+
+		event, err := row.event()
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read events: %w", err)
+	}
+
+	return events, nil
+}
+
+// MemAuditLog keeps events in process. It is safe for concurrent use.
+type MemAuditLog struct {
+	mu     sync.RWMutex
+	events []Event
+}
+
+func NewMemAuditLog() *MemAuditLog {
+	return &MemAuditLog{}
+}
+
+func (l *MemAuditLog) Record(event Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, event)
+}
+
+func (l *MemAuditLog) History(_ context.Context, params HistoryParams) ([]Event, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var events []Event
+	for _, event := range l.events {
+		if event.ProjectId != params.ProjectId {
+			continue
+		}
+		if params.GoodId != 0 && event.GoodId != params.GoodId {
+			continue
+		}
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	if params.Offset >= int64(len(events)) {
+		return nil, nil
+	}
+	events = events[params.Offset:]
+
+	if params.Limit < int64(len(events)) {
+		events = events[:params.Limit]
+	}
+
+	return events, nil
+}
+
+func (r eventRow) event() (Event, error) {
+	eventTime, err := time.ParseInLocation(eventTimeLayout, r.EventTime, time.UTC)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to parse event time: %w", err)
+	}
+
+	before, err := unmarshalState(r.Before)
+	if err != nil {
+		return Event{}, err
+	}
+
+	after, err := unmarshalState(r.After)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		Id:        r.EventId,
+		Version:   r.Version,
+		Operation: Operation(r.Operation),
+		Time:      eventTime,
+		Actor:     r.Actor,
+		GoodId:    r.Id,
+		ProjectId: r.ProjectId,
+		Before:    before,
+		After:     after,
+	}, nil
+}
+
+func unmarshalState(state string) (*Good, error) {
+	if state == "" {
+		return nil, nil
+	}
+
+	var good Good
+	if err := json.Unmarshal([]byte(state), &good); err != nil {
+		return nil, fmt.Errorf("failed to decode good state: %w", err)
+	}
+
+	return &good, nil
+}
@@ -0,0 +1,111 @@
+package good
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"gogogo/pkg/cache"
+)
+
+// Cached listings are keyed by the generation of the project they were read
+// from. Invalidating a project bumps its generation, which makes every cached
+// page, filter and sort of that project unreachable at once; stale entries are
+// left for the TTL to clean up.
+const (
+	listGoodsKeyPrefix  = "listGoods"
+	goodsGenerationKey  = "goods:generation"
+	allProjectsCacheKey = "all"
+)
+
+func projectCacheKey(projectId int64) string {
+	if projectId == 0 {
+		return allProjectsCacheKey
+	}
+
+	return strconv.FormatInt(projectId, 10)
+}
+
+func generationKey(projectId int64) string {
+	return fmt.Sprintf("%s:%s", goodsGenerationKey, projectCacheKey(projectId))
+}
+
+// listGoodsCacheKey builds the key for a listing from the project generation
+// and a digest of every query parameter.
+func (s Service) listGoodsCacheKey(ctx context.Context, params ListGoodsParams) (string, error) {
+	var generation int64
+	raw, err := s.cacheGet(ctx, generationKey(params.ProjectId))
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		return "", err
+	}
+	if err == nil {
+		generation, err = strconv.ParseInt(string(raw), 10, 64)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	query, err := json.Marshal(params)
+	if err != nil {
+		return "", err
+	}
+	digest := sha1.Sum(query)
+
+	return fmt.Sprintf(
+		"%s:%s:%d:%s",
+		listGoodsKeyPrefix,
+		projectCacheKey(params.ProjectId),
+		generation,
+		hex.EncodeToString(digest[:]),
+	), nil
+}
+
+// invalidateGoods drops every cached listing that may contain goods of the
+// given projects, including listings that are not scoped to a project.
+func (s Service) invalidateGoods(ctx context.Context, projectIds ...int64) {
+	seen := map[int64]bool{0: true}
+	keys := []int64{0}
+	for _, projectId := range projectIds {
+		if !seen[projectId] {
+			seen[projectId] = true
+			keys = append(keys, projectId)
+		}
+	}
+
+	for _, projectId := range keys {
+		_, err := s.cacheIncr(ctx, generationKey(projectId))
+		if err != nil {
+			log.Printf("error invalidating cache for project %s: %v", projectCacheKey(projectId), err)
+		}
+	}
+}
+
+// The cache helpers bound every cache call with CacheOperationTimeout so a slow
+// cache degrades to hitting the store instead of stalling requests.
+
+func (s Service) cacheGet(ctx context.Context, key string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, CacheOperationTimeout)
+	defer cancel()
+
+	return s.cache.Get(ctx, key)
+}
+
+func (s Service) cacheSet(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, CacheOperationTimeout)
+	defer cancel()
+
+	return s.cache.Set(ctx, key, value, ttl)
+}
+
+func (s Service) cacheIncr(ctx context.Context, key string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, CacheOperationTimeout)
+	defer cancel()
+
+	return s.cache.Incr(ctx, key)
+}
@@ -1,5 +1,25 @@
 package good
 
-const NotFoundErrorCode = 3
+import (
+	"time"
+
+	"gogogo/internal/apperror"
+)
+
+const NotFoundErrorCode = apperror.GoodNotFoundErrorCode
 const NotFoundErrorMessage = "errors.good.notFound"
-const ListGoodsRedisTTL = 60
+const ProjectNotFoundErrorCode = apperror.GoodProjectNotFoundErrorCode
+const ProjectNotFoundErrorMessage = "errors.good.projectNotFound"
+const ProjectArchivedErrorCode = apperror.GoodProjectArchivedErrorCode
+const ProjectArchivedErrorMessage = "errors.good.projectArchived"
+const AlreadyExistsErrorCode = apperror.GoodAlreadyExistsErrorCode
+const AlreadyExistsErrorMessage = "errors.good.alreadyExists"
+const ValidationErrorCode = apperror.GoodValidationErrorCode
+const ValidationErrorMessage = "errors.good.validation"
+const PreconditionFailedErrorCode = apperror.GoodPreconditionFailedErrorCode
+const PreconditionFailedErrorMessage = "errors.good.preconditionFailed"
+const NotRemovedErrorCode = apperror.GoodNotRemovedErrorCode
+const NotRemovedErrorMessage = "errors.good.notRemoved"
+const ListGoodsCacheTTL = time.Minute
+const CacheOperationTimeout = 500 * time.Millisecond
+const EventsSubject = "logs"
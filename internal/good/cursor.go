@@ -0,0 +1,62 @@
+package good
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"gogogo/internal/request"
+)
+
+var ErrInvalidCursor = request.ErrMalformedInput.WithDetails(map[string]string{"after": "is not a valid cursor"})
+
+// Cursor points right after a good in a listing ordered by SortBy. It is handed
+// to clients as an opaque string and lets the next page be fetched with a
+// keyset condition instead of an OFFSET scan.
+type Cursor struct {
+	SortBy    SortField `json:"s"`
+	SortDesc  bool      `json:"d,omitempty"`
+	Id        int64     `json:"i"`
+	Priority  int64     `json:"p,omitempty"`
+	Name      string    `json:"n,omitempty"`
+	CreatedAt time.Time `json:"c,omitempty"`
+}
+
+// NewCursor returns the cursor pointing after good in a listing with params' ordering.
+func NewCursor(params ListGoodsParams, good Good) Cursor {
+	cursor := Cursor{
+		SortBy:   params.SortBy,
+		SortDesc: params.SortDesc,
+		Id:       good.Id,
+	}
+
+	switch params.SortBy {
+	case SortByPriority:
+		cursor.Priority = good.Priority
+	case SortByName:
+		cursor.Name = good.Name
+	case SortByCreatedAt:
+		cursor.CreatedAt = good.CreatedAt
+	}
+
+	return cursor
+}
+
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func DecodeCursor(value string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(raw, &cursor); err != nil || !cursor.SortBy.Valid() {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,22 @@
+package good
+
+import "gogogo/internal/apperror"
+
+var (
+	ErrNotFound        = apperror.NotFound(NotFoundErrorCode, NotFoundErrorMessage)
+	ErrAlreadyExists   = apperror.Conflict(AlreadyExistsErrorCode, AlreadyExistsErrorMessage)
+	ErrProjectNotFound = apperror.Validation(ProjectNotFoundErrorCode, ProjectNotFoundErrorMessage).
+				WithDetails(map[string]string{"projectId": "project does not exist"})
+	ErrProjectArchived = apperror.Validation(ProjectArchivedErrorCode, ProjectArchivedErrorMessage).
+				WithDetails(map[string]string{"projectId": "project is archived"})
+	ErrValidation         = apperror.Validation(ValidationErrorCode, ValidationErrorMessage)
+	ErrNotRemoved         = apperror.Conflict(NotRemovedErrorCode, NotRemovedErrorMessage)
+	ErrPreconditionFailed = apperror.PreconditionFailed(PreconditionFailedErrorCode, PreconditionFailedErrorMessage).
+				WithDetails(map[string]string{"version": "the good was changed by another request"})
+
+	// ErrDuplicateInBatch rejects the repeated occurrences of a good in a batch.
+	ErrDuplicateInBatch = ErrValidation.WithDetails(map[string]string{"id": "appears more than once in the batch"})
+	// ErrRemovedPriority rejects setting the priority of a good that is or
+	// becomes removed, removed goods have no place in the priority sequence.
+	ErrRemovedPriority = ErrValidation.WithDetails(map[string]string{"priority": "can't be set on a removed good"})
+)
@@ -0,0 +1,128 @@
+package good
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gogogo/internal/actor"
+)
+
+// EventVersion is bumped whenever the event layout changes incompatibly.
+const EventVersion = 2
+
+type Operation string
+
+const (
+	OperationCreate       Operation = "create"
+	OperationUpdate       Operation = "update"
+	OperationDelete       Operation = "delete"
+	OperationReprioritize Operation = "reprioritize"
+	OperationRestore      Operation = "restore"
+	OperationPurge        Operation = "purge"
+)
+
+// Event describes a single change to a good. Before is nil for created goods,
+// After is nil for purged ones.
+type Event struct {
+	Id        string    `json:"id"`
+	Version   int       `json:"version"`
+	Operation Operation `json:"operation"`
+	Time      time.Time `json:"time"`
+	Actor     string    `json:"actor"`
+	GoodId    int64     `json:"goodId"`
+	ProjectId int64     `json:"projectId"`
+	Before    *Good     `json:"before"`
+	After     *Good     `json:"after"`
+}
+
+// NewEvent describes the change of a good from before to after made by the
+// actor of ctx.
+func NewEvent(ctx context.Context, operation Operation, before, after *Good) Event {
+	event := Event{
+		Id:        newEventId(),
+		Version:   EventVersion,
+		Operation: operation,
+		Time:      time.Now().UTC(),
+		Actor:     actor.FromContext(ctx),
+		Before:    before,
+		After:     after,
+	}
+
+	state := after
+	if state == nil {
+		state = before
+	}
+	if state != nil {
+		event.GoodId = state.Id
+		event.ProjectId = state.ProjectId
+	}
+
+	return event
+}
+
+// eventTimeLayout matches ClickHouse's DateTime64(3) text format.
+const eventTimeLayout = "2006-01-02 15:04:05.000"
+
+// eventRow is the flat layout of the ClickHouse logs table, good states are
+// embedded as JSON strings.
+type eventRow struct {
+	EventId   string `json:"EventId"`
+	Version   int    `json:"Version"`
+	Operation string `json:"Operation"`
+	EventTime string `json:"EventTime"`
+	Actor     string `json:"Actor"`
+	Id        int64  `json:"Id"`
+	ProjectId int64  `json:"ProjectId"`
+	Before    string `json:"Before"`
+	After     string `json:"After"`
+}
+
+func (e Event) MarshalRow() ([]byte, error) {
+	before, err := marshalState(e.Before)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := marshalState(e.After)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(eventRow{
+		EventId:   e.Id,
+		Version:   e.Version,
+		Operation: string(e.Operation),
+		EventTime: e.Time.UTC().Format(eventTimeLayout),
+		Actor:     e.Actor,
+		Id:        e.GoodId,
+		ProjectId: e.ProjectId,
+		Before:    before,
+		After:     after,
+	})
+}
+
+func marshalState(good *Good) (string, error) {
+	if good == nil {
+		return "", nil
+	}
+
+	state, err := json.Marshal(good)
+	if err != nil {
+		return "", err
+	}
+
+	return string(state), nil
+}
+
+// newEventId returns a random (version 4) UUID.
+func newEventId() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -1,60 +1,111 @@
 package good
 
 import (
-	"context"
 	"encoding/json"
-	"errors"
-	"log"
+	"fmt"
 	"net/http"
+	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+
+	"gogogo/internal/apperror"
+	"gogogo/internal/request"
+	"gogogo/pkg/validate"
 
 	"github.com/gorilla/schema"
-	"github.com/jackc/pgx/v4"
 )
 
 func init() {
 	decoder.IgnoreUnknownKeys(true)
+	decoder.RegisterConverter(time.Time{}, func(value string) reflect.Value {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return reflect.Value{}
+		}
+		return reflect.ValueOf(t)
+	})
 }
 
 var decoder = schema.NewDecoder()
 
-type ErrorResponse struct {
-	Code    int64             `json:"code"`
-	Message string            `json:"message"`
-	Details map[string]string `json:"details"`
-}
-
 func ListGoods(s Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		var params struct {
-			Limit  int64 `schema:"limit"`
-			Offset int64 `schema:"offset"`
+		var params ListGoodsQuery
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
+			return
 		}
 
-		if err := decoder.Decode(&params, r.URL.Query()); err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			return
+		var after *Cursor
+		if params.After != "" {
+			cursor, err := DecodeCursor(params.After)
+			if err != nil {
+				apperror.Write(w, err)
+				return
+			}
+
+			// the cursor carries the ordering it was issued for
+			if params.Sort == "" {
+				params.Sort = cursor.SortBy
+			}
+			if params.Order == "" && cursor.SortDesc {
+				params.Order = "desc"
+			}
+
+			if params.Sort != cursor.SortBy || (params.Order == "desc") != cursor.SortDesc {
+				apperror.Write(w, ErrValidation.WithDetails(map[string]string{
+					"after": "was issued for a different sort order",
+				}))
+				return
+			}
+
+			after = &cursor
+		}
+
+		if params.Sort == "" {
+			params.Sort = SortById
 		}
 
 		var limit int64 = 10
-		var offset int64 = 1
+		var offset int64 = 0
 		if params.Limit > 0 {
 			limit = params.Limit
 		}
 
-		if params.Offset > 0 {
+		if params.Offset > 0 && after == nil {
 			offset = params.Offset
 		}
 
-		goods, err := s.ListGoods(r.Context(), ListGoodsParams{
-			Limit:  limit,
-			Offset: offset,
-		})
+		removed, err := params.removedFilter()
+		if err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		filter := GoodsFilter{
+			ProjectId:   params.ProjectId,
+			Removed:     removed,
+			CreatedFrom: params.CreatedFrom,
+			CreatedTo:   params.CreatedTo,
+			Search:      params.Search,
+		}
+
+		listParams := ListGoodsParams{
+			GoodsFilter: filter,
+			SortBy:      params.Sort,
+			SortDesc:    params.Order == "desc",
+			Limit:       limit,
+			After:       after,
+			Offset:      offset,
+		}
+
+		goods, err := s.ListGoods(r.Context(), listParams)
 
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			log.Printf("failed to list goods: %v", err)
+			apperror.Write(w, fmt.Errorf("failed to list goods: %w", err))
 			return
 		}
 
@@ -63,12 +114,19 @@ func ListGoods(s Service) http.HandlerFunc {
 			goods = make([]Good, 0)
 		}
 
+		var nextCursor string
+		if int64(len(goods)) == limit {
+			nextCursor = NewCursor(listParams, goods[len(goods)-1]).Encode()
+		}
+
 		response := ListGoodsResponse{
 			Meta: ListGoodsMeta{
-				Total:   s.store.Count(r.Context()),
-				Removed: s.store.RemovedCount(r.Context()),
-				Limit:   limit,
-				Offset:  offset,
+				ProjectId:  filter.ProjectId,
+				Total:      s.store.Count(r.Context(), filter),
+				Removed:    s.store.RemovedCount(r.Context(), filter),
+				Limit:      limit,
+				Offset:     offset,
+				NextCursor: nextCursor,
 			},
 			Goods: goods,
 		}
@@ -77,59 +135,51 @@ func ListGoods(s Service) http.HandlerFunc {
 	}
 }
 
-func CreateGood(s Service) http.HandlerFunc {
+func GetGood(s Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		projectId, err := strconv.Atoi(r.URL.Query().Get("projectId"))
-		if r.URL.Query().Get("projectId") == "" || err != nil {
-			res := make(map[string]string)
-			res["error"] = "projectId is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		var params QueryParams
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
 			return
 		}
 
-		var params CreateGoodParams
-		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		good, err := s.GetGood(r.Context(), params)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to get good: %w", err))
 			return
 		}
 
-		if params.Name == "" {
-			res := make(map[string]string)
-			res["error"] = "name is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		tag := etag(good)
+		w.Header().Set("ETag", tag)
+		if etagMatches(r.Header.Get("If-None-Match"), tag) {
+			w.WriteHeader(http.StatusNotModified)
 			return
 		}
 
-		if params.Id == 0 {
-			res := make(map[string]string)
-			res["error"] = "id is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		_ = json.NewEncoder(w).Encode(good)
+	}
+}
+
+func CreateGood(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var queryParams ProjectQueryParams
+		var params CreateGoodParams
+		if err := decodeRequest(r, &queryParams, &params); err != nil {
+			apperror.Write(w, err)
 			return
 		}
 
-		params.ProjectId = int64(projectId)
+		params.ProjectId = queryParams.ProjectId
 
-		result, err := s.CreateGood(context.Background(), params)
+		result, err := s.CreateGood(r.Context(), params)
 		if err != nil {
-			log.Printf("failed to create good: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			apperror.Write(w, fmt.Errorf("failed to create good: %w", err))
 			return
 		}
 
-		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", etag(result))
 		w.WriteHeader(http.StatusCreated)
 		_ = json.NewEncoder(w).Encode(result)
 	}
@@ -138,127 +188,88 @@ func CreateGood(s Service) http.HandlerFunc {
 func DeleteGood(s Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		var params QueryParams
-		if err := decoder.Decode(&params, r.URL.Query()); err != nil {
-			res := make(map[string]string)
-			res["error"] = "id and projectId query params are required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		var params DeleteGoodQuery
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
 			return
 		}
 
-		good, err := s.DeleteGood(r.Context(), params)
+		version, err := expectedVersion(r, s, params.QueryParams, params.Version)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				_ = json.NewEncoder(w).Encode(ErrorResponse{
-					Code:    NotFoundErrorCode,
-					Message: NotFoundErrorMessage,
-					Details: make(map[string]string),
-				})
-				return
-			}
+			apperror.Write(w, err)
+			return
+		}
 
-			log.Printf("failed to delete good: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+		good, err := s.DeleteGood(r.Context(), params.QueryParams, version)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to delete good: %w", err))
 			return
 		}
 
+		w.Header().Set("ETag", etag(good))
+
 		_ = json.NewEncoder(w).Encode(struct {
 			Id        int64 `json:"id"`
 			ProjectId int64 `json:"projectId"`
 			Removed   bool  `json:"removed"`
+			Version   int64 `json:"version"`
 		}{
 			Id:        good.Id,
 			ProjectId: good.ProjectId,
 			Removed:   good.Removed,
+			Version:   good.Version,
 		})
 	}
 }
 
-func UpdateGood(s Service) http.HandlerFunc {
+func RestoreGood(s Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
-		var queryParams QueryParams
-		if err := decoder.Decode(&queryParams, r.URL.Query()); err != nil {
-			res := make(map[string]string)
-			res["error"] = "id and projectId query params are required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		var params QueryParams
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
 			return
 		}
 
-		var params UpdateGoodParams
-		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		good, err := s.RestoreGood(r.Context(), params)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to restore good: %w", err))
 			return
 		}
 
-		if params.Name == "" {
-			res := make(map[string]string)
-			res["error"] = "name is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-			return
-		}
+		w.Header().Set("ETag", etag(good))
 
-		if params.Priority == 0 {
-			res := make(map[string]string)
-			res["error"] = "priority field is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-			return
-		}
+		_ = json.NewEncoder(w).Encode(good)
+	}
+}
 
-		if params.Removed == nil {
-			res := make(map[string]string)
-			res["error"] = "removed field is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+// UpdateGood applies the JSON merge patch in the body to the good, only the
+// fields present in it change.
+func UpdateGood(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var queryParams QueryParams
+		var patch GoodPatch
+		if err := decodeRequest(r, &queryParams, &patch); err != nil {
+			apperror.Write(w, err)
 			return
 		}
 
-		if params.ProjectId == 0 {
-			res := make(map[string]string)
-			res["error"] = "projectId field is required or cannot be equal to 0"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		version, err := expectedVersion(r, s, queryParams, patch.Version)
+		if err != nil {
+			apperror.Write(w, err)
 			return
 		}
+		patch.Version = version
 
-		good, err := s.UpdateGood(r.Context(), queryParams.Id, queryParams.ProjectId, params)
+		good, err := s.UpdateGood(r.Context(), queryParams.Id, queryParams.ProjectId, patch)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				_ = json.NewEncoder(w).Encode(ErrorResponse{
-					Code:    NotFoundErrorCode,
-					Message: NotFoundErrorMessage,
-					Details: make(map[string]string),
-				})
-				return
-			}
-
-			log.Printf("failed to delete good: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			apperror.Write(w, fmt.Errorf("failed to update good: %w", err))
 			return
 		}
 
+		w.Header().Set("ETag", etag(good))
+
 		_ = json.NewEncoder(w).Encode(good)
 	}
 }
@@ -267,47 +278,22 @@ func ReprioritizeGood(s Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		var queryParams QueryParams
-		if err := decoder.Decode(&queryParams, r.URL.Query()); err != nil {
-			res := make(map[string]string)
-			res["error"] = "id and projectId query params are required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
-			return
-		}
-
 		var params ReprioritizeGoodParams
-		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
+		if err := decodeRequest(r, &queryParams, &params); err != nil {
+			apperror.Write(w, err)
 			return
 		}
 
-		if params.NewPriority == 0 {
-			res := make(map[string]string)
-			res["error"] = "newPriority field is required"
-			w.WriteHeader(http.StatusBadRequest)
-			err := json.NewEncoder(w).Encode(res)
-			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-			}
+		version, err := expectedVersion(r, s, queryParams, params.Version)
+		if err != nil {
+			apperror.Write(w, err)
 			return
 		}
+		params.Version = version
 
 		goods, err := s.ReprioritizeGood(r.Context(), queryParams.Id, queryParams.ProjectId, params)
 		if err != nil {
-			if errors.Is(err, pgx.ErrNoRows) {
-				_ = json.NewEncoder(w).Encode(ErrorResponse{
-					Code:    NotFoundErrorCode,
-					Message: NotFoundErrorMessage,
-					Details: make(map[string]string),
-				})
-				return
-			}
-
-			log.Printf("failed to reprioritize good: %v", err)
-			w.WriteHeader(http.StatusInternalServerError)
+			apperror.Write(w, fmt.Errorf("failed to reprioritize good: %w", err))
 			return
 		}
 
@@ -318,3 +304,251 @@ func ReprioritizeGood(s Service) http.HandlerFunc {
 		})
 	}
 }
+
+func CreateGoods(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var queryParams ProjectQueryParams
+		var params BatchCreateRequest
+		if err := decodeRequest(r, &queryParams, &params); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		writeBatch(w, params.Goods, "create", func(goods []CreateGoodParams) ([]BatchOutcome, error) {
+			return s.CreateGoods(r.Context(), queryParams.ProjectId, goods)
+		})
+	}
+}
+
+func UpdateGoods(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var queryParams ProjectQueryParams
+		var params BatchUpdateRequest
+		if err := decodeRequest(r, &queryParams, &params); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		writeBatch(w, params.Goods, "update", func(items []BatchUpdateItem) ([]BatchOutcome, error) {
+			return s.UpdateGoods(r.Context(), queryParams.ProjectId, items)
+		})
+	}
+}
+
+func DeleteGoods(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var queryParams ProjectQueryParams
+		var params BatchDeleteRequest
+		if err := decodeRequest(r, &queryParams, &params); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		items := make([]batchDeleteItem, 0, len(params.Ids))
+		for _, id := range params.Ids {
+			items = append(items, batchDeleteItem{Id: id})
+		}
+
+		writeBatch(w, items, "delete", func(items []batchDeleteItem) ([]BatchOutcome, error) {
+			ids := make([]int64, 0, len(items))
+			for _, item := range items {
+				ids = append(ids, item.Id)
+			}
+
+			return s.DeleteGoods(r.Context(), queryParams.ProjectId, ids)
+		})
+	}
+}
+
+// writeBatch validates every item on its own, hands the valid ones to apply
+// and responds with the outcome of each item in request order.
+func writeBatch[T any](w http.ResponseWriter, items []T, action string, apply func([]T) ([]BatchOutcome, error)) {
+	outcomes := make([]BatchOutcome, len(items))
+	var valid []T
+	var positions []int
+	for i, item := range items {
+		if failures := validate.Struct(item); len(failures) > 0 {
+			outcomes[i].Err = ErrValidation.WithDetails(failures)
+			continue
+		}
+
+		valid = append(valid, item)
+		positions = append(positions, i)
+	}
+
+	if len(valid) > 0 {
+		applied, err := apply(valid)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to %s goods: %w", action, err))
+			return
+		}
+
+		for i, outcome := range applied {
+			outcomes[positions[i]] = outcome
+		}
+	}
+
+	response := BatchResponse{
+		Results: make([]BatchResult, 0, len(outcomes)),
+	}
+	for i, outcome := range outcomes {
+		result := BatchResult{Index: i}
+		if outcome.Err != nil {
+			errResponse, _ := apperror.Response(outcome.Err)
+			result.Error = &errResponse
+			response.Failed++
+		} else {
+			good := outcome.Good
+			result.Good = &good
+			response.Succeeded++
+		}
+
+		response.Results = append(response.Results, result)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func GoodHistory(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params GoodHistoryQuery
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		writeHistory(w, r, s, params.HistoryQuery, params.Id)
+	}
+}
+
+func ProjectHistory(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params HistoryQuery
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		writeHistory(w, r, s, params, 0)
+	}
+}
+
+// etag derives the entity tag of the good from its row version.
+func etag(good Good) string {
+	return fmt.Sprintf(`"%d"`, good.Version)
+}
+
+// etagMatches reports whether the If-None-Match header value lists tag, weak
+// tags are compared by their opaque part.
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// expectedVersion returns the version the request requires the good to be
+// at, taken from the If-Match header or else the version passed in the
+// request. Zero means the request is unconditional. If-Match is compared
+// strongly, so weak tags never match, and when it lists several tags the one of
+// the good's current version is required.
+func expectedVersion(r *http.Request, s Service, params QueryParams, version int64) (int64, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" || header == "*" {
+		return version, nil
+	}
+
+	matched, err := ifMatchVersions(header)
+	if err != nil {
+		return 0, err
+	}
+
+	if version != 0 {
+		if !slices.Contains(matched, version) {
+			return 0, ErrValidation.WithDetails(map[string]string{
+				"version": "does not match If-Match",
+			})
+		}
+		return version, nil
+	}
+
+	switch len(matched) {
+	case 0:
+		return 0, ErrPreconditionFailed
+	case 1:
+		return matched[0], nil
+	}
+
+	// the store checks the version again, so a change made since is caught
+	good, err := s.GetGood(r.Context(), params)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get good: %w", err)
+	}
+	if !slices.Contains(matched, good.Version) {
+		return 0, ErrPreconditionFailed
+	}
+
+	return good.Version, nil
+}
+
+// ifMatchVersions parses the comma separated If-Match list and returns the
+// versions of its strong tags, weak tags are left out.
+func ifMatchVersions(header string) ([]int64, error) {
+	var versions []int64
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		weak := strings.HasPrefix(candidate, "W/")
+		candidate = strings.TrimPrefix(candidate, "W/")
+
+		unquoted, err := strconv.Unquote(candidate)
+		if err != nil {
+			unquoted = candidate
+		}
+		version, err := strconv.ParseInt(unquoted, 10, 64)
+		if err != nil || version < 1 {
+			return nil, request.ErrMalformedInput.WithDetails(map[string]string{
+				"If-Match": "must list ETags of the good",
+			})
+		}
+
+		if !weak {
+			versions = append(versions, version)
+		}
+	}
+
+	return versions, nil
+}
+
+func writeHistory(w http.ResponseWriter, r *http.Request, s Service, query HistoryQuery, goodId int64) {
+	var limit int64 = 100
+	if query.Limit > 0 {
+		limit = query.Limit
+	}
+
+	events, err := s.History(r.Context(), HistoryParams{
+		ProjectId: query.ProjectId,
+		GoodId:    goodId,
+		Limit:     limit,
+		Offset:    query.Offset,
+	})
+	if err != nil {
+		apperror.Write(w, fmt.Errorf("failed to read good history: %w", err))
+		return
+	}
+
+	if events == nil {
+		events = make([]Event, 0)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(HistoryResponse{
+		Limit:  limit,
+		Offset: query.Offset,
+		Events: events,
+	})
+}
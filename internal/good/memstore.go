@@ -0,0 +1,544 @@
+package good
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemStore is a thread-safe in-memory Store that mirrors PgStore semantics. It
+// is meant for tests, the store conformance suite keeps the two in line.
+// Change events are recorded into an in-process audit log instead of the
+// outbox.
+type MemStore struct {
+	mu sync.RWMutex
+	// projects tells whether each known project is archived.
+	projects map[int64]bool
+	goods    map[int64]Good
+	lastId   int64
+	audit    *MemAuditLog
+}
+
+var _ Store = (*MemStore)(nil)
+
+// NewMemStore creates an empty store where goods may reference the given projects.
+func NewMemStore(projectIds ...int64) *MemStore {
+	s := &MemStore{
+		projects: make(map[int64]bool),
+		goods:    make(map[int64]Good),
+		audit:    NewMemAuditLog(),
+	}
+	for _, id := range projectIds {
+		s.projects[id] = false
+	}
+
+	return s
+}
+
+// AddProject makes the project available for goods to reference.
+func (s *MemStore) AddProject(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.projects[id] = false
+}
+
+// ArchiveProject archives the project, goods can no longer be added to it.
+func (s *MemStore) ArchiveProject(id int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.projects[id]; ok {
+		s.projects[id] = true
+	}
+}
+
+// Audit returns the log the store records change events into.
+func (s *MemStore) Audit() *MemAuditLog {
+	return s.audit
+}
+
+func (f GoodsFilter) match(good Good) bool {
+	if f.ProjectId != 0 && good.ProjectId != f.ProjectId {
+		return false
+	}
+
+	if f.Removed != nil && good.Removed != *f.Removed {
+		return false
+	}
+
+	if !f.CreatedFrom.IsZero() && good.CreatedAt.Before(f.CreatedFrom) {
+		return false
+	}
+
+	if !f.CreatedTo.IsZero() && !good.CreatedAt.Before(f.CreatedTo) {
+		return false
+	}
+
+	if f.Search != "" {
+		search := strings.ToLower(f.Search)
+		if !strings.Contains(strings.ToLower(good.Name), search) &&
+			!strings.Contains(strings.ToLower(good.Description), search) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// compareGoods orders a and b by the sort column, then by id, returning a
+// negative number when a comes first in ascending order.
+func compareGoods(sortBy SortField, a, b Good) int {
+	var res int
+	switch sortBy {
+	case SortByPriority:
+		res = compareInt(a.Priority, b.Priority)
+	case SortByName:
+		res = strings.Compare(a.Name, b.Name)
+	case SortByCreatedAt:
+		res = a.CreatedAt.Compare(b.CreatedAt)
+	}
+
+	if res == 0 {
+		res = compareInt(a.Id, b.Id)
+	}
+
+	return res
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+func (s *MemStore) filter(filter GoodsFilter) []Good {
+	var goods []Good
+	for _, good := range s.goods {
+		if filter.match(good) {
+			goods = append(goods, good)
+		}
+	}
+
+	return goods
+}
+
+func (s *MemStore) ListGoods(_ context.Context, params ListGoodsParams) ([]Good, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	goods := s.filter(params.GoodsFilter)
+	less := func(a, b Good) bool {
+		if params.SortDesc {
+			return compareGoods(params.SortBy, a, b) > 0
+		}
+		return compareGoods(params.SortBy, a, b) < 0
+	}
+	sort.Slice(goods, func(i, j int) bool {
+		return less(goods[i], goods[j])
+	})
+
+	offset := params.Offset
+	if params.After != nil {
+		after := Good{
+			Id:        params.After.Id,
+			Priority:  params.After.Priority,
+			Name:      params.After.Name,
+			CreatedAt: params.After.CreatedAt,
+		}
+		offset = int64(sort.Search(len(goods), func(i int) bool {
+			return less(after, goods[i])
+		}))
+	}
+
+	if offset >= int64(len(goods)) {
+		return nil, nil
+	}
+	goods = goods[offset:]
+
+	if params.Limit < int64(len(goods)) {
+		goods = goods[:params.Limit]
+	}
+
+	return goods, nil
+}
+
+func (s *MemStore) Count(_ context.Context, filter GoodsFilter) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return int64(len(s.filter(filter)))
+}
+
+func (s *MemStore) RemovedCount(_ context.Context, filter GoodsFilter) int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	removed := true
+	filter.Removed = &removed
+
+	return int64(len(s.filter(filter)))
+}
+
+func (s *MemStore) CreateGood(ctx context.Context, good Good) (Good, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.createGood(ctx, good)
+}
+
+func (s *MemStore) createGood(ctx context.Context, good Good) (Good, error) {
+	if err := checkProject(s.projects, good.ProjectId); err != nil {
+		return Good{}, err
+	}
+
+	if good.Id == 0 {
+		good.Id = s.lastId + 1
+	}
+	if _, ok := s.goods[good.Id]; ok {
+		return Good{}, ErrAlreadyExists
+	}
+	if good.Id > s.lastId {
+		s.lastId = good.Id
+	}
+
+	good.Priority = s.nextPriority(good.ProjectId)
+
+	good = s.put(good)
+	s.audit.Record(NewEvent(ctx, OperationCreate, nil, &good))
+
+	return good, nil
+}
+
+// nextPriority returns the priority that places a good last among the active
+// goods of the project.
+func (s *MemStore) nextPriority(projectId int64) int64 {
+	var maxPriority int64
+	for _, g := range s.goods {
+		if g.ProjectId == projectId && !g.Removed && g.Priority > maxPriority {
+			maxPriority = g.Priority
+		}
+	}
+
+	return maxPriority + 1
+}
+
+// put stores the good, incrementing its version.
+func (s *MemStore) put(good Good) Good {
+	good.Version++
+	s.goods[good.Id] = good
+
+	return good
+}
+
+// lookup returns the good with id within the project, or ErrNotFound.
+func (s *MemStore) lookup(id, projectId int64) (Good, error) {
+	good, ok := s.goods[id]
+	if !ok || good.ProjectId != projectId {
+		return Good{}, ErrNotFound
+	}
+
+	return good, nil
+}
+
+func (s *MemStore) GetGood(_ context.Context, id, projectId int64) (Good, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookup(id, projectId)
+}
+
+func (s *MemStore) DeleteGood(ctx context.Context, id, projectId, version int64) (Good, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteGood(ctx, id, projectId, version)
+}
+
+func (s *MemStore) deleteGood(ctx context.Context, id, projectId, version int64) (Good, error) {
+	good, err := s.lookup(id, projectId)
+	if err != nil {
+		return Good{}, err
+	}
+	if err = checkVersion(good, version); err != nil {
+		return Good{}, err
+	}
+
+	if good.Removed {
+		return good, nil
+	}
+
+	before := good
+	now := time.Now()
+	good.Removed = true
+	good.RemovedAt = &now
+	good = s.put(good)
+	s.audit.Record(NewEvent(ctx, OperationDelete, &before, &good))
+
+	s.closePriorityGap(ctx, projectId, before.Priority)
+
+	return good, nil
+}
+
+// closePriorityGap moves the active goods of the project ranked below the
+// vacated priority up by one.
+func (s *MemStore) closePriorityGap(ctx context.Context, projectId, vacated int64) {
+	for _, g := range s.goods {
+		if g.ProjectId == projectId && !g.Removed && g.Priority > vacated {
+			previous, shifted := g, g
+			shifted.Priority--
+			shifted = s.put(shifted)
+			s.audit.Record(NewEvent(ctx, OperationReprioritize, &previous, &shifted))
+		}
+	}
+}
+
+func (s *MemStore) RestoreGood(ctx context.Context, id, projectId int64) (Good, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	good, err := s.lookup(id, projectId)
+	if err != nil {
+		return Good{}, err
+	}
+
+	if !good.Removed {
+		return Good{}, ErrNotRemoved
+	}
+	if err = checkProject(s.projects, projectId); err != nil {
+		return Good{}, err
+	}
+
+	before := good
+	good.Removed = false
+	good.RemovedAt = nil
+	good.Priority = s.nextPriority(projectId)
+	good = s.put(good)
+	s.audit.Record(NewEvent(ctx, OperationRestore, &before, &good))
+
+	return good, nil
+}
+
+func (s *MemStore) PurgeRemovedGoods(ctx context.Context, olderThan time.Duration, limit int) ([]Good, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-olderThan)
+	var expired []Good
+	for _, good := range s.goods {
+		if good.Removed && good.RemovedAt != nil && good.RemovedAt.Before(cutoff) {
+			expired = append(expired, good)
+		}
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].RemovedAt.Before(*expired[j].RemovedAt)
+	})
+
+	if limit < len(expired) {
+		expired = expired[:limit]
+	}
+
+	for _, good := range expired {
+		before := good
+		delete(s.goods, good.Id)
+		s.audit.Record(NewEvent(ctx, OperationPurge, &before, nil))
+	}
+
+	return expired, nil
+}
+
+func (s *MemStore) UpdateGood(ctx context.Context, id, projectId int64, patch GoodPatch) (Good, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.updateGood(ctx, id, projectId, patch)
+}
+
+func (s *MemStore) updateGood(ctx context.Context, id, projectId int64, patch GoodPatch) (Good, error) {
+	good, err := s.lookup(id, projectId)
+	if err != nil {
+		return Good{}, err
+	}
+	if err = checkVersion(good, patch.Version); err != nil {
+		return Good{}, err
+	}
+
+	before := good
+	good = patch.apply(good)
+	if good == before && !patch.Priority.Set {
+		return good, nil
+	}
+
+	leaves := !before.Removed && (good.Removed || good.ProjectId != projectId)
+	joins := !good.Removed && (before.Removed || good.ProjectId != projectId)
+	if joins || good.ProjectId != projectId {
+		if err = checkProject(s.projects, good.ProjectId); err != nil {
+			return Good{}, err
+		}
+	}
+	if patch.Priority.Set && good.Removed {
+		return Good{}, ErrRemovedPriority
+	}
+
+	if joins {
+		good.Priority = s.nextPriority(good.ProjectId)
+	}
+	if !good.Removed {
+		good.RemovedAt = nil
+	} else if good.RemovedAt == nil {
+		now := time.Now()
+		good.RemovedAt = &now
+	}
+	good = s.put(good)
+	s.audit.Record(NewEvent(ctx, OperationUpdate, &before, &good))
+
+	if leaves {
+		s.closePriorityGap(ctx, projectId, before.Priority)
+	}
+
+	if patch.Priority.Set {
+		if _, err = s.reposition(ctx, good.ProjectId, id, patch.Priority.Value, 0); err != nil {
+			return Good{}, err
+		}
+		good = s.goods[id]
+	}
+
+	return good, nil
+}
+
+// CreateGoods creates the goods one by one, rejecting the ones whose id is
+// taken.
+func (s *MemStore) CreateGoods(ctx context.Context, projectId int64, goods []Good) ([]BatchOutcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := checkProject(s.projects, projectId); err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]BatchOutcome, len(goods))
+	seen := make(map[int64]bool)
+	for i, good := range goods {
+		// goods without an id get a generated one, so they can't repeat
+		if good.Id != 0 && seen[good.Id] {
+			outcomes[i].Err = ErrDuplicateInBatch
+			continue
+		}
+		seen[good.Id] = true
+
+		good.ProjectId = projectId
+		outcomes[i].Good, outcomes[i].Err = s.createGood(ctx, good)
+	}
+
+	return outcomes, nil
+}
+
+func (s *MemStore) UpdateGoods(ctx context.Context, projectId int64, items []BatchUpdateItem) ([]BatchOutcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcomes := make([]BatchOutcome, len(items))
+	updated := make(map[int64]bool)
+	for i, item := range items {
+		if updated[item.Id] {
+			outcomes[i].Err = ErrDuplicateInBatch
+			continue
+		}
+
+		_, outcomes[i].Err = s.updateGood(ctx, item.Id, projectId, item.patch())
+		updated[item.Id] = outcomes[i].Err == nil
+	}
+
+	// later updates may have shifted the goods updated before them
+	for i, item := range items {
+		if outcomes[i].Err == nil {
+			outcomes[i].Good = s.goods[item.Id]
+		}
+	}
+
+	return outcomes, nil
+}
+
+func (s *MemStore) DeleteGoods(ctx context.Context, projectId int64, ids []int64) ([]BatchOutcome, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcomes := make([]BatchOutcome, len(ids))
+	seen := make(map[int64]bool)
+	for i, id := range ids {
+		if seen[id] {
+			outcomes[i].Err = ErrDuplicateInBatch
+			continue
+		}
+		seen[id] = true
+
+		outcomes[i].Good, outcomes[i].Err = s.deleteGood(ctx, id, projectId, 0)
+	}
+
+	return outcomes, nil
+}
+
+func (s *MemStore) ReprioritizeGood(
+	ctx context.Context,
+	id,
+	projectId int64,
+	params ReprioritizeGoodParams,
+) ([]ReprioritizedGood, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.reposition(ctx, projectId, id, params.NewPriority, params.Version)
+}
+
+// reposition moves the active good to position newPriority within the project
+// and returns every good whose priority changed. A non-zero version must match
+// the moved good's current one.
+func (s *MemStore) reposition(
+	ctx context.Context,
+	projectId,
+	id,
+	newPriority,
+	version int64,
+) ([]ReprioritizedGood, error) {
+	var project []Good
+	for _, good := range s.goods {
+		if good.ProjectId == projectId && !good.Removed {
+			project = append(project, good)
+		}
+	}
+	sort.Slice(project, func(i, j int) bool {
+		return compareGoods(SortByPriority, project[i], project[j]) < 0
+	})
+
+	ordered := make([]ReprioritizedGood, 0, len(project))
+	for _, good := range project {
+		ordered = append(ordered, ReprioritizedGood{Id: good.Id, Priority: good.Priority})
+	}
+
+	changed, ok := reorder(ordered, id, newPriority)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	if err := checkVersion(s.goods[id], version); err != nil {
+		return nil, err
+	}
+
+	for i, c := range changed {
+		before := s.goods[c.Id]
+		good := before
+		good.Priority = c.Priority
+		good = s.put(good)
+		changed[i].Version = good.Version
+		s.audit.Record(NewEvent(ctx, OperationReprioritize, &before, &good))
+	}
+
+	return changed, nil
+}
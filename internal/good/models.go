@@ -4,18 +4,15 @@ import "time"
 
 // Good model
 type Good struct {
-	Id          int64     `json:"id"`
-	ProjectId   int64     `json:"projectId"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Priority    int64     `json:"priority"`
-	Removed     bool      `json:"removed"`
-	CreatedAt   time.Time `json:"createdAt"`
-}
-
-// Project model
-type Project struct {
-	Id        int64
-	Name      string
-	CreatedAt time.Time
+	Id          int64      `json:"id"`
+	ProjectId   int64      `json:"projectId"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Priority    int64      `json:"priority"`
+	Removed     bool       `json:"removed"`
+	RemovedAt   *time.Time `json:"removedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	// Version is incremented whenever the good is written, it is also exposed
+	// as the ETag.
+	Version int64 `json:"version"`
 }
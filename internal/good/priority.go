@@ -0,0 +1,41 @@
+package good
+
+// reorder moves the good with the given id to position newPriority in goods,
+// which must be ordered by priority. The whole list is renumbered densely from
+// 1 and only the goods whose priority changed are returned, in the new order.
+// ok is false when the good is not in the list.
+func reorder(goods []ReprioritizedGood, id, newPriority int64) (changed []ReprioritizedGood, ok bool) {
+	position := -1
+	for i, good := range goods {
+		if good.Id == id {
+			position = i
+			break
+		}
+	}
+
+	if position == -1 {
+		return nil, false
+	}
+
+	newPosition := int(newPriority) - 1
+	if newPosition < 0 {
+		newPosition = 0
+	}
+	if newPosition > len(goods)-1 {
+		newPosition = len(goods) - 1
+	}
+
+	ordered := make([]ReprioritizedGood, 0, len(goods))
+	ordered = append(ordered, goods[:position]...)
+	ordered = append(ordered, goods[position+1:]...)
+	ordered = append(ordered[:newPosition], append([]ReprioritizedGood{goods[position]}, ordered[newPosition:]...)...)
+
+	changed = make([]ReprioritizedGood, 0)
+	for i, good := range ordered {
+		if good.Priority != int64(i+1) {
+			changed = append(changed, ReprioritizedGood{Id: good.Id, Priority: int64(i + 1)})
+		}
+	}
+
+	return changed, true
+}
@@ -0,0 +1,50 @@
+package good
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+type PurgeConfig struct {
+	// Retention is how long removed goods are kept before being purged.
+	Retention time.Duration
+	// Interval is how often removed goods are looked for.
+	Interval  time.Duration
+	BatchSize int
+}
+
+// Purger permanently deletes goods that have been removed for longer than the
+// retention period.
+type Purger struct {
+	service Service
+	cfg     PurgeConfig
+}
+
+func NewPurger(service Service, cfg PurgeConfig) *Purger {
+	return &Purger{
+		service: service,
+		cfg:     cfg,
+	}
+}
+
+// Run purges expired goods every interval until ctx is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	for {
+		goods, err := p.service.PurgeRemovedGoods(ctx, p.cfg.Retention, p.cfg.BatchSize)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("goods purge: %v", err)
+		}
+
+		// keep purging while full batches are deleted
+		if err == nil && len(goods) == p.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.cfg.Interval):
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package good
+
+import (
+	"net/http"
+
+	"gogogo/internal/request"
+)
+
+func decodeRequest(r *http.Request, query, body any) error {
+	return request.Decode(r, decoder, query, body, ErrValidation)
+}
@@ -3,42 +3,56 @@ package good
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"log"
 	"time"
 
-	"github.com/gomodule/redigo/redis"
+	"gogogo/pkg/cache"
 )
 
 type Store interface {
 	ListGoods(ctx context.Context, params ListGoodsParams) ([]Good, error)
+	GetGood(ctx context.Context, id, projectId int64) (Good, error)
 	CreateGood(ctx context.Context, good Good) (Good, error)
-	DeleteGood(ctx context.Context, id, projectId int64) (Good, error)
-	UpdateGood(ctx context.Context, id, projectId int64, params UpdateGoodParams) (Good, error)
+	DeleteGood(ctx context.Context, id, projectId, version int64) (Good, error)
+	UpdateGood(ctx context.Context, id, projectId int64, patch GoodPatch) (Good, error)
 
-	Count(ctx context.Context) int64
-	RemovedCount(ctx context.Context) int64
+	Count(ctx context.Context, filter GoodsFilter) int64
+	RemovedCount(ctx context.Context, filter GoodsFilter) int64
 
-	ReprioritizeGood(ctx context.Context, id, projectId int64, params ReprioritizeGoodParams) (Good, error)
-	GetReprioritizedGoods(ctx context.Context, id int64) ([]ReprioritizedGood, error)
+	ReprioritizeGood(ctx context.Context, id, projectId int64, params ReprioritizeGoodParams) ([]ReprioritizedGood, error)
+
+	CreateGoods(ctx context.Context, projectId int64, goods []Good) ([]BatchOutcome, error)
+	UpdateGoods(ctx context.Context, projectId int64, items []BatchUpdateItem) ([]BatchOutcome, error)
+	DeleteGoods(ctx context.Context, projectId int64, ids []int64) ([]BatchOutcome, error)
+
+	RestoreGood(ctx context.Context, id, projectId int64) (Good, error)
+	PurgeRemovedGoods(ctx context.Context, olderThan time.Duration, limit int) ([]Good, error)
 }
 
 type Service struct {
 	store Store
-	cache redis.Conn
+	cache cache.Cache
+	audit AuditReader
 }
 
-func NewService(store Store, cache redis.Conn) Service {
+func NewService(store Store, cache cache.Cache, audit AuditReader) Service {
 	return Service{
 		store: store,
 		cache: cache,
+		audit: audit,
 	}
 }
 
 func (s Service) ListGoods(ctx context.Context, params ListGoodsParams) ([]Good, error) {
 	var goods []Good
-	res, err := s.cache.Do("GET", "listGoods")
-	if err != nil {
-		log.Printf("error getting data from redis: %v", err)
+	var res []byte
+	key, err := s.listGoodsCacheKey(ctx, params)
+	if err == nil {
+		res, err = s.cacheGet(ctx, key)
+	}
+	if err != nil && !errors.Is(err, cache.ErrMiss) {
+		log.Printf("error getting data from cache: %v", err)
 	}
 
 	if res == nil {
@@ -47,24 +61,24 @@ func (s Service) ListGoods(ctx context.Context, params ListGoodsParams) ([]Good,
 			return nil, err
 		}
 
-		goodsToRedis, err := json.Marshal(goods)
+		goodsToCache, err := json.Marshal(goods)
 		if err != nil {
-			log.Printf("error marshaling data for redis: %v", err)
-		} else {
-			_, err = s.cache.Do("SETEX", "listGoods", ListGoodsRedisTTL, goodsToRedis)
+			log.Printf("error marshaling data for cache: %v", err)
+		} else if key != "" {
+			err = s.cacheSet(ctx, key, goodsToCache, ListGoodsCacheTTL)
 			if err != nil {
-				log.Printf("error putting data to redis: %v", err)
+				log.Printf("error putting data to cache: %v", err)
 			}
 		}
 
 		return goods, nil
 	}
 
-	err = json.Unmarshal(res.([]byte), &goods)
+	err = json.Unmarshal(res, &goods)
 	if err != nil {
-		log.Printf("error unmarshaling data from redis: %v", err)
+		log.Printf("error unmarshaling data from cache: %v", err)
 
-		// get goods from db if unmarshalling from redis is unsuccessful
+		// get goods from db if unmarshalling from cache is unsuccessful
 		goods, err = s.store.ListGoods(ctx, params)
 		if err != nil {
 			return nil, err
@@ -74,6 +88,10 @@ func (s Service) ListGoods(ctx context.Context, params ListGoodsParams) ([]Good,
 	return goods, nil
 }
 
+func (s Service) GetGood(ctx context.Context, params QueryParams) (Good, error) {
+	return s.store.GetGood(ctx, params.Id, params.ProjectId)
+}
+
 func (s Service) CreateGood(ctx context.Context, params CreateGoodParams) (Good, error) {
 	good := Good{
 		Id:          params.Id,
@@ -89,34 +107,67 @@ func (s Service) CreateGood(ctx context.Context, params CreateGoodParams) (Good,
 		return Good{}, err
 	}
 
+	s.invalidateGoods(ctx, res.ProjectId)
+
 	return res, nil
 }
 
-func (s Service) DeleteGood(ctx context.Context, params QueryParams) (Good, error) {
-	good, err := s.store.DeleteGood(ctx, params.Id, params.ProjectId)
+// DeleteGood removes the good, a non-zero version must match its current one.
+func (s Service) DeleteGood(ctx context.Context, params QueryParams, version int64) (Good, error) {
+	good, err := s.store.DeleteGood(ctx, params.Id, params.ProjectId, version)
+	if err != nil {
+		return Good{}, err
+	}
+
+	s.invalidateGoods(ctx, good.ProjectId)
+
+	return good, nil
+}
+
+func (s Service) RestoreGood(ctx context.Context, params QueryParams) (Good, error) {
+	good, err := s.store.RestoreGood(ctx, params.Id, params.ProjectId)
 	if err != nil {
 		return Good{}, err
 	}
 
-	ok, err := s.cache.Do("DEL", "listGoods")
-	log.Printf("invalidating cache, ok: %v, err: %v", ok, err)
+	s.invalidateGoods(ctx, good.ProjectId)
 
 	return good, nil
 }
 
+// PurgeRemovedGoods permanently deletes up to limit goods removed more than
+// olderThan ago.
+func (s Service) PurgeRemovedGoods(ctx context.Context, olderThan time.Duration, limit int) ([]Good, error) {
+	goods, err := s.store.PurgeRemovedGoods(ctx, olderThan, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(goods) > 0 {
+		projectIds := make([]int64, 0, len(goods))
+		for _, good := range goods {
+			projectIds = append(projectIds, good.ProjectId)
+		}
+		s.invalidateGoods(ctx, projectIds...)
+	}
+
+	return goods, nil
+}
+
+// UpdateGood applies the merge patch to the good.
 func (s Service) UpdateGood(
 	ctx context.Context,
 	id,
 	projectId int64,
-	params UpdateGoodParams,
+	patch GoodPatch,
 ) (Good, error) {
-	good, err := s.store.UpdateGood(ctx, id, projectId, params)
+	good, err := s.store.UpdateGood(ctx, id, projectId, patch)
 	if err != nil {
 		return Good{}, err
 	}
 
-	ok, err := s.cache.Do("DEL", "listGoods")
-	log.Printf("invalidating cache, ok: %v, err: %v", ok, err)
+	// the good may have been moved to another project
+	s.invalidateGoods(ctx, projectId, good.ProjectId)
 
 	return good, nil
 }
@@ -127,18 +178,68 @@ func (s Service) ReprioritizeGood(
 	projectId int64,
 	params ReprioritizeGoodParams,
 ) ([]ReprioritizedGood, error) {
-	good, err := s.store.ReprioritizeGood(ctx, id, projectId, params)
+	goods, err := s.store.ReprioritizeGood(ctx, id, projectId, params)
 	if err != nil {
 		return make([]ReprioritizedGood, 0), err
 	}
 
-	goods, err := s.store.GetReprioritizedGoods(ctx, good.Id)
+	s.invalidateGoods(ctx, projectId)
+
+	return goods, nil
+}
+
+func (s Service) CreateGoods(ctx context.Context, projectId int64, params []CreateGoodParams) ([]BatchOutcome, error) {
+	now := time.Now()
+	goods := make([]Good, 0, len(params))
+	for _, p := range params {
+		goods = append(goods, Good{
+			Id:          p.Id,
+			ProjectId:   projectId,
+			Name:        p.Name,
+			Description: p.Description,
+			CreatedAt:   now,
+		})
+	}
+
+	outcomes, err := s.store.CreateGoods(ctx, projectId, goods)
 	if err != nil {
-		return make([]ReprioritizedGood, 0), err
+		return nil, err
 	}
 
-	ok, err := s.cache.Do("DEL", "listGoods")
-	log.Printf("invalidating cache, ok: %v, err: %v", ok, err)
+	s.invalidateGoods(ctx, projectId)
 
-	return goods, nil
+	return outcomes, nil
+}
+
+func (s Service) UpdateGoods(ctx context.Context, projectId int64, items []BatchUpdateItem) ([]BatchOutcome, error) {
+	outcomes, err := s.store.UpdateGoods(ctx, projectId, items)
+	if err != nil {
+		return nil, err
+	}
+
+	// goods may have been moved to other projects
+	projectIds := []int64{projectId}
+	for _, outcome := range outcomes {
+		if outcome.Err == nil {
+			projectIds = append(projectIds, outcome.Good.ProjectId)
+		}
+	}
+	s.invalidateGoods(ctx, projectIds...)
+
+	return outcomes, nil
+}
+
+func (s Service) DeleteGoods(ctx context.Context, projectId int64, ids []int64) ([]BatchOutcome, error) {
+	outcomes, err := s.store.DeleteGoods(ctx, projectId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateGoods(ctx, projectId)
+
+	return outcomes, nil
+}
+
+func (s Service) History(ctx context.Context, params HistoryParams) ([]Event, error) {
+	return s.audit.History(ctx, params)
 }
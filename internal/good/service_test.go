@@ -0,0 +1,182 @@
+package good
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	"gogogo/internal/request"
+	"gogogo/pkg/cache"
+	"gogogo/pkg/cache/memory"
+)
+
+// countingStore counts the listings that reach the store, that is the ones
+// the cache missed.
+type countingStore struct {
+	Store
+	lists atomic.Int64
+}
+
+func (s *countingStore) ListGoods(ctx context.Context, params ListGoodsParams) ([]Good, error) {
+	s.lists.Add(1)
+	return s.Store.ListGoods(ctx, params)
+}
+
+// TestServiceInvalidatesListings checks that every write bumps the cache
+// generation of the projects it touches and of the listing across all
+// projects, so listings read afterwards miss the cache.
+func TestServiceInvalidatesListings(t *testing.T) {
+	const source, target = 1, 2
+	active := false
+
+	tests := []struct {
+		name string
+		// write changes the goods a, b and c of the source project and returns
+		// the projects whose listings it affects.
+		write func(t *testing.T, s Service, a, b, c Good) []int64
+	}{
+		{"create", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.CreateGood(context.Background(), CreateGoodParams{ProjectId: source, Name: "d"})
+			return touched(t, err, source)
+		}},
+		{"update", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.UpdateGood(context.Background(), a.Id, source, GoodPatch{
+				Name: request.PatchField[string]{Set: true, Value: "renamed"},
+			})
+			return touched(t, err, source)
+		}},
+		{"update moving the good", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.UpdateGood(context.Background(), a.Id, source, GoodPatch{
+				ProjectId: request.PatchField[int64]{Set: true, Value: target},
+			})
+			return touched(t, err, source, target)
+		}},
+		{"delete", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.DeleteGood(context.Background(), QueryParams{Id: a.Id, ProjectId: source}, 0)
+			return touched(t, err, source)
+		}},
+		{"restore", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			// deleted through the store, so only the restore invalidates
+			_, err := s.store.DeleteGood(context.Background(), a.Id, source, 0)
+			if err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+
+			_, err = s.RestoreGood(context.Background(), QueryParams{Id: a.Id, ProjectId: source})
+			return touched(t, err, source)
+		}},
+		{"reprioritize", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.ReprioritizeGood(context.Background(), c.Id, source, ReprioritizeGoodParams{NewPriority: 1})
+			return touched(t, err, source)
+		}},
+		{"batch create", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.CreateGoods(context.Background(), source, []CreateGoodParams{{Name: "d"}, {Name: "e"}})
+			return touched(t, err, source)
+		}},
+		{"batch update moving a good", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.UpdateGoods(context.Background(), source, []BatchUpdateItem{
+				{Id: a.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: target, Name: "a", Removed: &active}},
+				{Id: b.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: source, Name: "b", Removed: &active}},
+			})
+			return touched(t, err, source, target)
+		}},
+		{"batch delete", func(t *testing.T, s Service, a, b, c Good) []int64 {
+			_, err := s.DeleteGoods(context.Background(), source, []int64{a.Id, b.Id})
+			return touched(t, err, source)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			memStore := NewMemStore(source, target)
+			store := &countingStore{Store: memStore}
+			c := memory.New(0)
+			s := NewService(store, c, memStore.Audit())
+
+			var goods []Good
+			for _, name := range []string{"a", "b", "c"} {
+				good, err := store.CreateGood(ctx, Good{ProjectId: source, Name: name})
+				if err != nil {
+					t.Fatalf("create good: %v", err)
+				}
+				goods = append(goods, good)
+			}
+
+			// warm the cache with every listing the write may affect
+			listings := []int64{0, source, target}
+			for _, projectId := range listings {
+				listGoods(t, s, projectId)
+				listGoods(t, s, projectId)
+			}
+			if got := store.lists.Load(); got != int64(len(listings)) {
+				t.Fatalf("%d listings reached the store while warming, want %d", got, len(listings))
+			}
+
+			before := make(map[int64]int64)
+			for _, projectId := range listings {
+				before[projectId] = generation(t, c, projectId)
+			}
+
+			affected := append(tt.write(t, s, goods[0], goods[1], goods[2]), 0)
+
+			for _, projectId := range affected {
+				if got := generation(t, c, projectId); got <= before[projectId] {
+					t.Errorf("generation of %s = %d, want it bumped past %d",
+						projectCacheKey(projectId), got, before[projectId])
+				}
+
+				lists := store.lists.Load()
+				listGoods(t, s, projectId)
+				if store.lists.Load() == lists {
+					t.Errorf("listing of %s was served from the cache after the write", projectCacheKey(projectId))
+				}
+			}
+		})
+	}
+}
+
+// touched fails the test on a write error and returns the projects given.
+func touched(t *testing.T, err error, projectIds ...int64) []int64 {
+	t.Helper()
+
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	return projectIds
+}
+
+func listGoods(t *testing.T, s Service, projectId int64) {
+	t.Helper()
+
+	_, err := s.ListGoods(context.Background(), ListGoodsParams{
+		GoodsFilter: GoodsFilter{ProjectId: projectId},
+		SortBy:      SortByPriority,
+		Limit:       100,
+	})
+	if err != nil {
+		t.Fatalf("list goods: %v", err)
+	}
+}
+
+func generation(t *testing.T, c cache.Cache, projectId int64) int64 {
+	t.Helper()
+
+	raw, err := c.Get(context.Background(), generationKey(projectId))
+	if errors.Is(err, cache.ErrMiss) {
+		return 0
+	}
+	if err != nil {
+		t.Fatalf("get generation: %v", err)
+	}
+
+	generation, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		t.Fatalf("parse generation: %v", err)
+	}
+
+	return generation
+}
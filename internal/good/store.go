@@ -2,57 +2,364 @@ package good
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
 
+	"gogogo/internal/apperror"
+	"gogogo/internal/outbox"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"github.com/nats-io/nats.go"
 )
 
+// priorityLockClass namespaces the advisory locks that serialise priority
+// assignment; the second lock key is the project id.
+const priorityLockClass = 1
+
+// idSequenceLockClass namespaces the advisory lock that serialises advancing
+// the goods id sequence past client supplied ids.
+const idSequenceLockClass = 2
+
 type PgStore struct {
-	Pool   *pgxpool.Pool
-	logger *nats.Conn
+	Pool *pgxpool.Pool
 }
 
-func NewStore(Pool *pgxpool.Pool, logger *nats.Conn) Store {
+func NewStore(Pool *pgxpool.Pool) Store {
 	return PgStore{
-		Pool:   Pool,
-		logger: logger,
-	}
-}
-
-func (s PgStore) logToClickHouse(good Good) {
-	payload, err := json.Marshal(struct {
-		Id          int64  `json:"Id"`
-		ProjectId   int64  `json:"ProjectId"`
-		Name        string `json:"Name"`
-		Description string `json:"Description"`
-		Priority    int64  `json:"Priority"`
-		Removed     bool   `json:"Removed"`
-		CreatedAt   string `json:"EventTime"`
-	}{
-		Id:          good.Id,
-		ProjectId:   good.ProjectId,
-		Name:        good.Name,
-		Description: good.Description,
-		Priority:    good.Priority,
-		Removed:     good.Removed,
-		CreatedAt:   good.CreatedAt.Format("2006-01-02 15:04:05"),
-	})
+		Pool: Pool,
+	}
+}
+
+// Postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	uniqueViolation        = "23505"
+	foreignKeyViolation    = "23503"
+	numericValueOutOfRange = "22003"
+)
+
+// checkVersion fails with ErrPreconditionFailed unless version is zero or the
+// good's current version.
+func checkVersion(good Good, version int64) error {
+	if version != 0 && good.Version != version {
+		return ErrPreconditionFailed
+	}
+
+	return nil
+}
+
+// mapError translates driver errors into the package's domain errors, other
+// errors are returned as is.
+func mapError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return ErrAlreadyExists.Wrap(err)
+		case foreignKeyViolation:
+			return ErrProjectNotFound.Wrap(err)
+		case numericValueOutOfRange:
+			return ErrValidation.Wrap(err)
+		}
+	}
+
+	return err
+}
+
+// lockPriorities serialises priority changes within a single project for the
+// rest of the transaction, leaving other projects unaffected.
+func lockPriorities(ctx context.Context, tx pgx.Tx, projectId int64) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, $2)", priorityLockClass, int32(projectId))
 	if err != nil {
-		log.Printf("failed to marshal payload for logging: %v", err)
-	} else {
-		err = s.logger.Publish("logs", payload)
-		if err != nil {
-			log.Printf("failed to log to nats: %v", err)
+		return fmt.Errorf("failed to acquire advisory tx lock: %w", err)
+	}
+
+	return nil
+}
+
+// lockProjects locks the rows of the existing projects among ids, so they can't
+// be deleted or archived before the transaction commits, and reports whether
+// each of them is archived.
+func lockProjects(ctx context.Context, tx pgx.Tx, ids []int64) (map[int64]bool, error) {
+	rows, err := tx.Query(ctx, `SELECT id, archived FROM projects WHERE id = ANY($1) FOR SHARE`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock projects: %w", err)
+	}
+	defer rows.Close()
+
+	archived := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		var isArchived bool
+		if err := rows.Scan(&id, &isArchived); err != nil {
+			return nil, fmt.Errorf("failed to lock projects: %w", err)
+		}
+
+		archived[id] = isArchived
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock projects: %w", err)
+	}
+
+	return archived, nil
+}
+
+// lockProject locks the project like lockProjects, failing unless goods may be
+// added to it.
+func lockProject(ctx context.Context, tx pgx.Tx, id int64) error {
+	archived, err := lockProjects(ctx, tx, []int64{id})
+	if err != nil {
+		return err
+	}
+
+	return checkProject(archived, id)
+}
+
+// checkProject tells why goods can't be added to the project, given the
+// projects locked by lockProjects, or returns nil if they can.
+func checkProject(archived map[int64]bool, id int64) error {
+	isArchived, ok := archived[id]
+	switch {
+	case !ok:
+		return ErrProjectNotFound
+	case isArchived:
+		return ErrProjectArchived
+	}
+
+	return nil
+}
+
+// advanceIdSequence moves the goods id sequence past id, so generated ids
+// never collide with the client supplied one.
+func advanceIdSequence(ctx context.Context, tx pgx.Tx, id int64) error {
+	_, err := tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1, 0)", idSequenceLockClass)
+	if err != nil {
+		return fmt.Errorf("failed to acquire advisory tx lock: %w", err)
+	}
+
+	_, err = tx.Exec(
+		ctx,
+		`SELECT setval(
+		pg_get_serial_sequence('goods', 'id'),
+		GREATEST($1::bigint, nextval(pg_get_serial_sequence('goods', 'id')))
+	)`,
+		id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to advance id sequence: %w", mapError(err))
+	}
+
+	return nil
+}
+
+// generateIds draws n ids from the goods id sequence.
+func generateIds(ctx context.Context, tx pgx.Tx, n int) ([]int64, error) {
+	if n == 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT nextval(pg_get_serial_sequence('goods', 'id')) FROM generate_series(1, $1)`,
+		n,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ids: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// nextPriority returns the priority that places a good last among the active
+// goods of the project. Callers must hold the project's priority lock.
+func nextPriority(ctx context.Context, tx pgx.Tx, projectId int64) (int64, error) {
+	var maxPriority int64
+	err := tx.QueryRow(
+		ctx,
+		`SELECT COALESCE(MAX(priority), 0) FROM goods WHERE project_id = $1 AND NOT removed`,
+		projectId,
+	).Scan(&maxPriority)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get max priority: %w", err)
+	}
+
+	return maxPriority + 1, nil
+}
+
+// logEvent records the change in the outbox as part of tx, so the event
+// reaches ClickHouse through NATS exactly when the change is committed.
+func logEvent(ctx context.Context, tx pgx.Tx, operation Operation, before, after *Good) error {
+	payload, err := NewEvent(ctx, operation, before, after).MarshalRow()
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	return outbox.Enqueue(ctx, tx, EventsSubject, payload)
+}
+
+// goodColumns lists the columns scanGood expects, in order.
+const goodColumns = "id, project_id, name, description, priority, removed, removed_at, created_at, version"
+
+// scanGood reads a row selected with goodColumns.
+func scanGood(row pgx.Row, good *Good) error {
+	return row.Scan(
+		&good.Id,
+		&good.ProjectId,
+		&good.Name,
+		&good.Description,
+		&good.Priority,
+		&good.Removed,
+		&good.RemovedAt,
+		&good.CreatedAt,
+		&good.Version,
+	)
+}
+
+// qualifiedGoodColumns is goodColumns for statements joining goods with other
+// relations.
+const qualifiedGoodColumns = "goods.id, goods.project_id, goods.name, goods.description, goods.priority, " +
+	"goods.removed, goods.removed_at, goods.created_at, goods.version"
+
+// scanGoods reads every row selected with goodColumns and closes rows.
+func scanGoods(rows pgx.Rows) ([]Good, error) {
+	defer rows.Close()
+
+	var goods []Good
+	for rows.Next() {
+		var good Good
+		if err := scanGood(rows, &good); err != nil {
+			return nil, err
 		}
+
+		goods = append(goods, good)
+	}
+
+	return goods, rows.Err()
+}
+
+// setPriorities assigns priorities[i] to the good ids[i] and returns the
+// updated goods.
+func setPriorities(ctx context.Context, tx pgx.Tx, ids, priorities []int64) ([]Good, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE goods
+	SET priority = new_priorities.priority, version = goods.version + 1
+	FROM unnest($1::bigint[], $2::bigint[]) AS new_priorities(id, priority)
+	WHERE goods.id = new_priorities.id
+	RETURNING `+qualifiedGoodColumns,
+		ids,
+		priorities,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update priorities: %w", err)
+	}
+
+	goods, err := scanGoods(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update priorities: %w", err)
+	}
+
+	return goods, nil
+}
+
+// conditions builds the SQL predicates for the filter, appending the
+// placeholders' values to args.
+func (f GoodsFilter) conditions(args []any) ([]string, []any) {
+	var conds []string
+	if f.ProjectId != 0 {
+		args = append(args, f.ProjectId)
+		conds = append(conds, fmt.Sprintf("project_id = $%d", len(args)))
+	}
+
+	if f.Removed != nil {
+		args = append(args, *f.Removed)
+		conds = append(conds, fmt.Sprintf("removed = $%d", len(args)))
+	}
+
+	if !f.CreatedFrom.IsZero() {
+		args = append(args, f.CreatedFrom)
+		conds = append(conds, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+
+	if !f.CreatedTo.IsZero() {
+		args = append(args, f.CreatedTo)
+		conds = append(conds, fmt.Sprintf("created_at < $%d", len(args)))
 	}
+
+	if f.Search != "" {
+		args = append(args, "%"+likeEscaper.Replace(f.Search)+"%")
+		conds = append(conds, fmt.Sprintf("(name ILIKE $%d OR description ILIKE $%d)", len(args), len(args)))
+	}
+
+	return conds, args
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// sortColumns maps the sort fields accepted by the API to goods columns.
+var sortColumns = map[SortField]string{
+	SortById:        "id",
+	SortByPriority:  "priority",
+	SortByName:      "name",
+	SortByCreatedAt: "created_at",
+}
+
+// orderClause orders by the requested column, breaking ties by id so that
+// paging through the result is stable.
+func (p ListGoodsParams) orderClause() string {
+	column, ok := sortColumns[p.SortBy]
+	if !ok {
+		column = sortColumns[SortById]
+	}
+
+	direction := "ASC"
+	if p.SortDesc {
+		direction = "DESC"
+	}
+
+	if column == "id" {
+		return "ORDER BY id " + direction
+	}
+
+	return fmt.Sprintf("ORDER BY %s %s, id %s", column, direction, direction)
+}
+
+func whereClause(conds []string) string {
+	if len(conds) == 0 {
+		return ""
+	}
+
+	return "WHERE " + strings.Join(conds, " AND ")
 }
 
-func (s PgStore) Count(ctx context.Context) int64 {
+func (s PgStore) Count(ctx context.Context, filter GoodsFilter) int64 {
+	conds, args := filter.conditions(nil)
+
 	var count int64
-	row := s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM goods`)
+	row := s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM goods `+whereClause(conds), args...)
 	err := row.Scan(&count)
 	if err != nil {
 		return 0
@@ -61,9 +368,15 @@ func (s PgStore) Count(ctx context.Context) int64 {
 	return count
 }
 
-func (s PgStore) RemovedCount(ctx context.Context) int64 {
+// RemovedCount counts the removed goods matching the filter, regardless of
+// whether the filter itself selects removed goods.
+func (s PgStore) RemovedCount(ctx context.Context, filter GoodsFilter) int64 {
+	filter.Removed = nil
+	conds, args := filter.conditions(nil)
+	conds = append(conds, "removed = true")
+
 	var count int64
-	row := s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM goods WHERE removed = true`)
+	row := s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM goods `+whereClause(conds), args...)
 	err := row.Scan(&count)
 	if err != nil {
 		return 0
@@ -72,65 +385,118 @@ func (s PgStore) RemovedCount(ctx context.Context) int64 {
 	return count
 }
 
+// afterCondition builds the keyset predicate selecting rows that follow the
+// cursor in the requested ordering.
+func (p ListGoodsParams) afterCondition(args []any) (string, []any) {
+	c := p.After
+	operator := ">"
+	if p.SortDesc {
+		operator = "<"
+	}
+
+	var value any
+	switch p.SortBy {
+	case SortByPriority:
+		value = c.Priority
+	case SortByName:
+		value = c.Name
+	case SortByCreatedAt:
+		value = c.CreatedAt
+	default:
+		args = append(args, c.Id)
+		return fmt.Sprintf("id %s $%d", operator, len(args)), args
+	}
+
+	args = append(args, value, c.Id)
+	return fmt.Sprintf(
+		"(%s, id) %s ($%d, $%d)",
+		sortColumns[p.SortBy],
+		operator,
+		len(args)-1,
+		len(args),
+	), args
+}
+
 func (s PgStore) ListGoods(ctx context.Context, params ListGoodsParams) ([]Good, error) {
+	conds, args := params.GoodsFilter.conditions(nil)
+
+	offset := params.Offset
+	if params.After != nil {
+		var cond string
+		cond, args = params.afterCondition(args)
+		conds = append(conds, cond)
+		offset = 0
+	}
+	args = append(args, params.Limit, offset)
+
 	rows, err := s.Pool.Query(
 		ctx,
-		`SELECT 
-		id,
-		project_id,
-		name,
-		description,
-		priority,
-		removed,
-		created_at
+		fmt.Sprintf(`SELECT %s
 	FROM
 		goods
-	ORDER BY id
-	LIMIT $1 OFFSET $2`,
-		params.Limit,
-		params.Offset,
+	%s
+	%s
+	LIMIT $%d OFFSET $%d`, goodColumns, whereClause(conds), params.orderClause(), len(args)-1, len(args)),
+		args...,
 	)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var goods []Good
-	for rows.Next() {
-		var good Good
-		if err := rows.Scan(
-			&good.Id,
-			&good.ProjectId,
-			&good.Name,
-			&good.Description,
-			&good.Priority,
-			&good.Removed,
-			&good.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
+	return scanGoods(rows)
+}
 
-		goods = append(goods, good)
+func (s PgStore) GetGood(ctx context.Context, id, projectId int64) (Good, error) {
+	row := s.Pool.QueryRow(
+		ctx,
+		`SELECT `+goodColumns+`
+	FROM
+		goods
+	WHERE id = $1 AND project_id = $2`,
+		id,
+		projectId,
+	)
+
+	var good Good
+	err := scanGood(row, &good)
+	if err != nil {
+		return Good{}, mapError(err)
 	}
-	rows.Close()
 
-	return goods, nil
+	return good, nil
 }
 
+// CreateGood inserts the good last among the project's active goods. The id is
+// generated unless the good carries one.
 func (s PgStore) CreateGood(ctx context.Context, good Good) (Good, error) {
 	tx, err := s.Pool.Begin(ctx)
 	if err != nil {
 		return Good{}, fmt.Errorf("failed to begin tx: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	_, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock(1)")
+	err = lockProject(ctx, tx, good.ProjectId)
 	if err != nil {
-		return Good{}, fmt.Errorf("failed to acquire advisory tx lock: %w", err)
+		return Good{}, err
 	}
 
-	var maxPriority int64
-	err = tx.QueryRow(ctx, `SELECT COALESCE(MAX(priority), 0) FROM goods`).Scan(&maxPriority)
-	maxPriority++
+	err = lockPriorities(ctx, tx, good.ProjectId)
+	if err != nil {
+		return Good{}, err
+	}
+
+	if good.Id != 0 {
+		err = advanceIdSequence(ctx, tx, good.Id)
+		if err != nil {
+			return Good{}, err
+		}
+	}
+
+	priority, err := nextPriority(ctx, tx, good.ProjectId)
+	if err != nil {
+		return Good{}, err
+	}
 
 	row := tx.QueryRow(
 		ctx,
@@ -144,29 +510,26 @@ func (s PgStore) CreateGood(ctx context.Context, good Good) (Good, error) {
     	created_at
 	)
 	VALUES
-		($1, $2, $3, $4, $5, $6, $7)
-	RETURNING id, project_id, name, description, priority, removed, created_at`,
+		(COALESCE(NULLIF($1::bigint, 0), nextval(pg_get_serial_sequence('goods', 'id'))), $2, $3, $4, $5, $6, $7)
+	RETURNING `+goodColumns,
 		good.Id,
 		good.ProjectId,
 		good.Name,
 		good.Description,
-		maxPriority,
+		priority,
 		good.Removed,
 		good.CreatedAt,
 	)
 
 	var createdGood Good
-	err = row.Scan(
-		&createdGood.Id,
-		&createdGood.ProjectId,
-		&createdGood.Name,
-		&createdGood.Description,
-		&createdGood.Priority,
-		&createdGood.Removed,
-		&createdGood.CreatedAt,
-	)
+	err = scanGood(row, &createdGood)
+	if err != nil {
+		return Good{}, fmt.Errorf("failed to scan rows: %w", mapError(err))
+	}
+
+	err = logEvent(ctx, tx, OperationCreate, nil, &createdGood)
 	if err != nil {
-		return Good{}, fmt.Errorf("failed to scan rows: %w", err)
+		return Good{}, err
 	}
 
 	err = tx.Commit(ctx)
@@ -178,27 +541,27 @@ func (s PgStore) CreateGood(ctx context.Context, good Good) (Good, error) {
 		return Good{}, fmt.Errorf("failed to commit tx: %w, rollbacked successfully", err)
 	}
 
-	s.logToClickHouse(good)
-
 	return createdGood, nil
 }
 
-func (s PgStore) DeleteGood(ctx context.Context, id, projectId int64) (Good, error) {
+// DeleteGood soft-deletes the good and closes the gap it leaves in the
+// project's priorities. Deleting a removed good is a no-op. A non-zero version
+// must match the good's current version.
+func (s PgStore) DeleteGood(ctx context.Context, id, projectId, version int64) (Good, error) {
 	tx, err := s.Pool.Begin(ctx)
 	if err != nil {
 		return Good{}, fmt.Errorf("failed to begin tx: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = lockPriorities(ctx, tx, projectId)
+	if err != nil {
+		return Good{}, err
+	}
 
 	row := tx.QueryRow(
 		ctx,
-		`SELECT 
-		id,
-		project_id,
-		name,
-		description,
-		priority,
-		removed,
-		created_at
+		`SELECT `+goodColumns+`
 	FROM
 		goods
 	WHERE id = $1 AND project_id = $2
@@ -208,42 +571,42 @@ func (s PgStore) DeleteGood(ctx context.Context, id, projectId int64) (Good, err
 	)
 
 	var good Good
-	err = row.Scan(
-		&good.Id,
-		&good.ProjectId,
-		&good.Name,
-		&good.Description,
-		&good.Priority,
-		&good.Removed,
-		&good.CreatedAt,
-	)
+	err = scanGood(row, &good)
 	if err != nil {
+		return Good{}, mapError(err)
+	}
+	if err = checkVersion(good, version); err != nil {
 		return Good{}, err
 	}
+	if good.Removed {
+		return good, nil
+	}
+	before := good
 
 	row = tx.QueryRow(
 		ctx,
 		`UPDATE goods 
-	SET removed = true 
+	SET removed = true, removed_at = now(), version = version + 1
 	WHERE id = $1 AND project_id = $2
-	RETURNING id, project_id, name, description, priority, removed, created_at`,
+	RETURNING `+goodColumns,
 		id,
 		projectId,
 	)
 
+	err = scanGood(row, &good)
 	if err != nil {
-		return Good{}, fmt.Errorf("failed to update row: %w", err)
+		return Good{}, fmt.Errorf("failed to update row: %w", mapError(err))
 	}
 
-	err = row.Scan(
-		&good.Id,
-		&good.ProjectId,
-		&good.Name,
-		&good.Description,
-		&good.Priority,
-		&good.Removed,
-		&good.CreatedAt,
-	)
+	err = logEvent(ctx, tx, OperationDelete, &before, &good)
+	if err != nil {
+		return Good{}, err
+	}
+
+	err = closePriorityGaps(ctx, tx, projectId, []int64{before.Priority})
+	if err != nil {
+		return Good{}, err
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -254,133 +617,228 @@ func (s PgStore) DeleteGood(ctx context.Context, id, projectId int64) (Good, err
 		return Good{}, fmt.Errorf("failed to commit tx: %w, rollbacked successfully", err)
 	}
 
-	s.logToClickHouse(good)
-
 	return good, nil
 }
 
-func (s PgStore) UpdateGood(ctx context.Context, id, projectId int64, params UpdateGoodParams) (Good, error) {
-	tx, err := s.Pool.Begin(ctx)
-	if err != nil {
-		return Good{}, fmt.Errorf("failed to begin tx: %w", err)
+// closePriorityGaps moves the active goods of the project up by the number of
+// vacated priorities ranked above them, after the goods at those priorities
+// have left the project's sequence. Callers must hold the project's priority
+// lock.
+func closePriorityGaps(ctx context.Context, tx pgx.Tx, projectId int64, vacated []int64) error {
+	if len(vacated) == 0 {
+		return nil
 	}
 
-	row := tx.QueryRow(
+	lowest := vacated[0]
+	for _, priority := range vacated {
+		lowest = min(lowest, priority)
+	}
+
+	rows, err := tx.Query(
 		ctx,
-		`SELECT 
-		id,
-		project_id,
-		name,
-		description,
-		priority,
-		removed,
-		created_at
-	FROM
-		goods
-	WHERE id = $1 AND project_id = $2
+		`SELECT `+goodColumns+`
+	FROM goods
+	WHERE project_id = $1 AND NOT removed AND priority > $2
 	FOR UPDATE`,
-		id,
 		projectId,
-	)
-
-	var good Good
-	err = row.Scan(
-		&good.Id,
-		&good.ProjectId,
-		&good.Name,
-		&good.Description,
-		&good.Priority,
-		&good.Removed,
-		&good.CreatedAt,
+		lowest,
 	)
+	if err != nil {
+		return fmt.Errorf("failed to select project goods: %w", err)
+	}
+
+	following, err := scanGoods(rows)
+	if err != nil {
+		return fmt.Errorf("failed to select project goods: %w", err)
+	}
+
+	previous := make(map[int64]Good, len(following))
+	var ids, priorities []int64
+	for _, good := range following {
+		var shift int64
+		for _, priority := range vacated {
+			if priority < good.Priority {
+				shift++
+			}
+		}
+
+		if shift > 0 {
+			previous[good.Id] = good
+			ids = append(ids, good.Id)
+			priorities = append(priorities, good.Priority-shift)
+		}
+	}
+
+	shifted, err := setPriorities(ctx, tx, ids, priorities)
+	if err != nil {
+		return err
+	}
+
+	for _, good := range shifted {
+		after := good
+		before := previous[good.Id]
+		err = logEvent(ctx, tx, OperationReprioritize, &before, &after)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RestoreGood undeletes the good, placing it last among the project's active
+// goods. Goods of archived projects stay removed.
+func (s PgStore) RestoreGood(ctx context.Context, id, projectId int64) (Good, error) {
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return Good{}, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = lockPriorities(ctx, tx, projectId)
 	if err != nil {
 		return Good{}, err
 	}
 
-	description := good.Description
-	if params.Description != "" {
-		description = params.Description
+	row := tx.QueryRow(
+		ctx,
+		`SELECT `+goodColumns+`
+	FROM
+		goods
+	WHERE id = $1 AND project_id = $2
+	FOR UPDATE`,
+		id,
+		projectId,
+	)
+
+	var good Good
+	err = scanGood(row, &good)
+	if err != nil {
+		return Good{}, mapError(err)
+	}
+	if !good.Removed {
+		return Good{}, ErrNotRemoved
+	}
+	before := good
+
+	err = lockProject(ctx, tx, projectId)
+	if err != nil {
+		return Good{}, err
+	}
+
+	priority, err := nextPriority(ctx, tx, projectId)
+	if err != nil {
+		return Good{}, err
 	}
 
 	row = tx.QueryRow(
 		ctx,
 		`UPDATE goods 
-	SET 
-	    project_id = $1,
-	    name = $2,
-		description = $3,
-	    priority = $4,
-	    removed = $5
-	WHERE id = $6 and project_id = $7
-	RETURNING id, project_id, name, description, priority, removed, created_at`,
-		params.ProjectId,
-		params.Name,
-		description,
-		params.Priority,
-		params.Removed,
+	SET removed = false, removed_at = NULL, priority = $3, version = version + 1
+	WHERE id = $1 AND project_id = $2
+	RETURNING `+goodColumns,
 		id,
 		projectId,
+		priority,
 	)
+
+	err = scanGood(row, &good)
 	if err != nil {
-		return Good{}, fmt.Errorf("failed to update row: %w", err)
+		return Good{}, fmt.Errorf("failed to update row: %w", mapError(err))
 	}
 
-	err = row.Scan(
-		&good.Id,
-		&good.ProjectId,
-		&good.Name,
-		&good.Description,
-		&good.Priority,
-		&good.Removed,
-		&good.CreatedAt,
-	)
+	err = logEvent(ctx, tx, OperationRestore, &before, &good)
+	if err != nil {
+		return Good{}, err
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		err = tx.Rollback(ctx)
+		return Good{}, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return good, nil
+}
+
+// PurgeRemovedGoods permanently deletes up to limit goods that were removed
+// more than olderThan ago and returns them.
+func (s PgStore) PurgeRemovedGoods(ctx context.Context, olderThan time.Duration, limit int) ([]Good, error) {
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(
+		ctx,
+		`DELETE FROM goods
+	WHERE id IN (
+		SELECT id
+		FROM goods
+		WHERE removed AND removed_at < now() - make_interval(secs => $1)
+		ORDER BY removed_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING `+goodColumns,
+		olderThan.Seconds(),
+		limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete goods: %w", err)
+	}
+
+	purged, err := scanGoods(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete goods: %w", err)
+	}
+
+	for _, good := range purged {
+		before := good
+		err = logEvent(ctx, tx, OperationPurge, &before, nil)
 		if err != nil {
-			return Good{}, fmt.Errorf("failed to rollback tx: %w", err)
+			return nil, err
 		}
-		return Good{}, fmt.Errorf("failed to commit tx: %w, rollbacked successfully", err)
 	}
 
-	s.logToClickHouse(good)
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
 
-	return good, nil
+	return purged, nil
 }
 
-func (s PgStore) ReprioritizeGood(ctx context.Context, id, projectId int64, params ReprioritizeGoodParams) (Good, error) {
+// UpdateGood applies the merge patch to the good: only the fields present in
+// the patch change, a null description clears it. A good leaving the active
+// goods of its project, by being removed or moved, closes the gap it leaves,
+// and one joining them is placed last. A new priority moves the good within its
+// project like ReprioritizeGood does. A patch changing nothing leaves the good
+// and its version as they are.
+func (s PgStore) UpdateGood(ctx context.Context, id, projectId int64, patch GoodPatch) (Good, error) {
 	tx, err := s.Pool.Begin(ctx)
 	if err != nil {
 		return Good{}, fmt.Errorf("failed to begin tx: %w", err)
 	}
+	defer func() { _ = tx.Rollback(ctx) }()
 
-	_, err = tx.Exec(
-		ctx,
-		`
-		WITH updated_row AS (
-			UPDATE goods
-			SET priority = $1
-			WHERE id = $2
-			RETURNING id
-		), subsequent_rows AS (
-			SELECT id, priority
-			FROM goods
-			WHERE id > (SELECT id FROM updated_row)
-			ORDER BY id
-		), new_priorities AS (
-			SELECT id, $1 + ROW_NUMBER() OVER (ORDER BY id) AS new_priority
-			FROM subsequent_rows
-		)
-		UPDATE goods
-		SET priority = new_priorities.new_priority
-		FROM new_priorities
-		WHERE goods.id = new_priorities.id`,
-		params.NewPriority,
-		id,
-	)
+	targetId := projectId
+	if patch.ProjectId.Set {
+		targetId = patch.ProjectId.Value
+	}
+
+	// lock in a fixed order, so concurrent moves between two projects can't
+	// deadlock
+	for _, lockId := range sortedUnique(projectId, targetId) {
+		err = lockPriorities(ctx, tx, lockId)
+		if err != nil {
+			return Good{}, err
+		}
+	}
+
+	good, err := updateGood(ctx, tx, id, projectId, patch)
 	if err != nil {
-		return Good{}, fmt.Errorf("failed to update row: %w", err)
+		return Good{}, err
 	}
 
 	err = tx.Commit(ctx)
@@ -392,66 +850,628 @@ func (s PgStore) ReprioritizeGood(ctx context.Context, id, projectId int64, para
 		return Good{}, fmt.Errorf("failed to commit tx: %w, rollbacked successfully", err)
 	}
 
-	row := s.Pool.QueryRow(
+	return good, nil
+}
+
+// updateGood applies the merge patch to the good as UpdateGood describes.
+// Callers must hold the priority locks of the good's project and of the project
+// it is moved to.
+func updateGood(ctx context.Context, tx pgx.Tx, id, projectId int64, patch GoodPatch) (Good, error) {
+	row := tx.QueryRow(
 		ctx,
-		`
-    	SELECT id, project_id, name, description, priority, removed, created_at
-    	FROM goods
-    	WHERE id = $1 AND project_id = $2`,
+		`SELECT `+goodColumns+`
+	FROM
+		goods
+	WHERE id = $1 AND project_id = $2
+	FOR UPDATE`,
 		id,
 		projectId,
 	)
 
 	var good Good
-	err = row.Scan(
-		&good.Id,
-		&good.ProjectId,
-		&good.Name,
-		&good.Description,
-		&good.Priority,
-		&good.Removed,
-		&good.CreatedAt,
+	err := scanGood(row, &good)
+	if err != nil {
+		return Good{}, mapError(err)
+	}
+	if err = checkVersion(good, patch.Version); err != nil {
+		return Good{}, err
+	}
+	before := good
+
+	after := patch.apply(good)
+	if after == good && !patch.Priority.Set {
+		return good, nil
+	}
+
+	leaves := !good.Removed && (after.Removed || after.ProjectId != good.ProjectId)
+	joins := !after.Removed && (good.Removed || after.ProjectId != good.ProjectId)
+	if joins || after.ProjectId != projectId {
+		err = lockProject(ctx, tx, after.ProjectId)
+		if err != nil {
+			return Good{}, err
+		}
+	}
+	if patch.Priority.Set && after.Removed {
+		return Good{}, ErrRemovedPriority
+	}
+
+	if joins {
+		after.Priority, err = nextPriority(ctx, tx, after.ProjectId)
+		if err != nil {
+			return Good{}, err
+		}
+	} else {
+		after.Priority = good.Priority
+	}
+
+	row = tx.QueryRow(
+		ctx,
+		`UPDATE goods 
+	SET 
+	    project_id = $1,
+	    name = $2,
+		description = $3,
+	    priority = $4,
+	    removed = $5,
+	    removed_at = CASE
+	        WHEN NOT $5 THEN NULL
+	        ELSE COALESCE(removed_at, now())
+	    END,
+	    version = version + 1
+	WHERE id = $6 and project_id = $7
+	RETURNING `+goodColumns,
+		after.ProjectId,
+		after.Name,
+		after.Description,
+		after.Priority,
+		after.Removed,
+		id,
+		projectId,
 	)
 
+	err = scanGood(row, &good)
+	if err != nil {
+		return Good{}, fmt.Errorf("failed to update row: %w", mapError(err))
+	}
+
+	err = logEvent(ctx, tx, OperationUpdate, &before, &good)
 	if err != nil {
 		return Good{}, err
 	}
 
-	s.logToClickHouse(good)
+	if leaves {
+		err = closePriorityGaps(ctx, tx, before.ProjectId, []int64{before.Priority})
+		if err != nil {
+			return Good{}, err
+		}
+	}
+
+	if patch.Priority.Set {
+		changed, err := repositionGood(ctx, tx, good.ProjectId, id, patch.Priority.Value, 0)
+		if err != nil {
+			return Good{}, err
+		}
+
+		for _, moved := range changed {
+			if moved.Id == id {
+				good = moved
+			}
+		}
+	}
 
 	return good, nil
 }
 
-func (s PgStore) GetReprioritizedGoods(ctx context.Context, id int64) ([]ReprioritizedGood, error) {
-	rows, err := s.Pool.Query(
-		ctx,
-		`SELECT 
-		id,
-		priority
-		FROM
-			goods
-		WHERE id >= $1
-		ORDER BY id`,
-		id,
-	)
+// sortedUnique returns the distinct ids in ascending order.
+func sortedUnique(ids ...int64) []int64 {
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
 
+	return slices.Compact(ids)
+}
+
+// ReprioritizeGood moves the good to position params.NewPriority within its
+// project, shifting the goods in between by one. Priorities in the project are
+// renumbered densely starting from 1, and every good whose priority changed is
+// returned ordered by its new priority.
+func (s PgStore) ReprioritizeGood(
+	ctx context.Context,
+	id,
+	projectId int64,
+	params ReprioritizeGoodParams,
+) ([]ReprioritizedGood, error) {
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = lockPriorities(ctx, tx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := repositionGood(ctx, tx, projectId, id, params.NewPriority, params.Version)
 	if err != nil {
 		return nil, err
 	}
 
-	var goods []ReprioritizedGood
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	sort.Slice(changed, func(i, j int) bool {
+		return changed[i].Priority < changed[j].Priority
+	})
+
+	reprioritized := make([]ReprioritizedGood, 0, len(changed))
+	for _, good := range changed {
+		reprioritized = append(reprioritized, ReprioritizedGood{
+			Id:       good.Id,
+			Priority: good.Priority,
+			Version:  good.Version,
+		})
+	}
+
+	return reprioritized, nil
+}
+
+// repositionGood moves the active good to position newPriority within the
+// project, renumbering the project's active goods densely from 1, and returns
+// every good whose priority changed. A non-zero version must match the moved
+// good's current one. Callers must hold the project's priority lock.
+func repositionGood(ctx context.Context, tx pgx.Tx, projectId, id, newPriority, version int64) ([]Good, error) {
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, priority, version
+	FROM goods
+	WHERE project_id = $1 AND NOT removed
+	ORDER BY priority, id
+	FOR UPDATE`,
+		projectId,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select project goods: %w", err)
+	}
+
+	var ordered []ReprioritizedGood
 	for rows.Next() {
 		var good ReprioritizedGood
-		if err := rows.Scan(
-			&good.Id,
-			&good.Priority,
-		); err != nil {
+		if err := rows.Scan(&good.Id, &good.Priority, &good.Version); err != nil {
+			rows.Close()
 			return nil, err
 		}
 
-		goods = append(goods, good)
+		ordered = append(ordered, good)
 	}
 	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
 
-	return goods, nil
+	moved, ok := reorder(ordered, id, newPriority)
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	for _, good := range ordered {
+		if good.Id == id && version != 0 && good.Version != version {
+			return nil, ErrPreconditionFailed
+		}
+	}
+
+	if len(moved) == 0 {
+		return nil, nil
+	}
+
+	var ids, priorities []int64
+	for _, good := range moved {
+		ids = append(ids, good.Id)
+		priorities = append(priorities, good.Priority)
+	}
+
+	changed, err := setPriorities(ctx, tx, ids, priorities)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[int64]int64, len(ordered))
+	for _, good := range ordered {
+		previous[good.Id] = good.Priority
+	}
+
+	for _, good := range changed {
+		after := good
+		before := good
+		before.Priority = previous[good.Id]
+		before.Version--
+		err = logEvent(ctx, tx, OperationReprioritize, &before, &after)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return changed, nil
+}
+
+// selectIds returns the set of ids the query, selecting a single id column,
+// yields.
+func selectIds(ctx context.Context, tx pgx.Tx, query string, args ...any) (map[int64]bool, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// lockProjectGoods selects the goods of the project with the given ids for
+// update, keyed by id.
+func lockProjectGoods(ctx context.Context, tx pgx.Tx, projectId int64, ids []int64) (map[int64]Good, error) {
+	rows, err := tx.Query(
+		ctx,
+		`SELECT `+goodColumns+`
+	FROM goods
+	WHERE project_id = $1 AND id = ANY($2)
+	FOR UPDATE`,
+		projectId,
+		ids,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select goods: %w", err)
+	}
+
+	goods, err := scanGoods(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select goods: %w", err)
+	}
+
+	byId := make(map[int64]Good, len(goods))
+	for _, good := range goods {
+		byId[good.Id] = good
+	}
+
+	return byId, nil
+}
+
+// CreateGoods inserts the goods into the project in a single statement,
+// placing them last among its active goods in the given order. Ids are
+// generated for goods without one, goods whose id is taken are rejected
+// individually.
+func (s PgStore) CreateGoods(ctx context.Context, projectId int64, goods []Good) ([]BatchOutcome, error) {
+	outcomes := make([]BatchOutcome, len(goods))
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = lockProject(ctx, tx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = lockPriorities(ctx, tx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	goods = slices.Clone(goods)
+	var ids []int64
+	var maxId int64
+	generated := 0
+	for _, good := range goods {
+		if good.Id == 0 {
+			generated++
+			continue
+		}
+
+		ids = append(ids, good.Id)
+		maxId = max(maxId, good.Id)
+	}
+	taken, err := selectIds(ctx, tx, `SELECT id FROM goods WHERE id = ANY($1)`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select existing goods: %w", err)
+	}
+
+	if maxId != 0 {
+		err = advanceIdSequence(ctx, tx, maxId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	generatedIds, err := generateIds(ctx, tx, generated)
+	if err != nil {
+		return nil, err
+	}
+	for i := range goods {
+		if goods[i].Id == 0 {
+			goods[i].Id, generatedIds = generatedIds[0], generatedIds[1:]
+		}
+	}
+
+	priority, err := nextPriority(ctx, tx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []int
+	var newIds, priorities []int64
+	var names, descriptions []string
+	var createdAt []time.Time
+	for i, good := range goods {
+		switch {
+		case taken[good.Id]:
+			outcomes[i].Err = ErrAlreadyExists
+			continue
+		case slices.Contains(newIds, good.Id):
+			outcomes[i].Err = ErrDuplicateInBatch
+			continue
+		}
+
+		accepted = append(accepted, i)
+		newIds = append(newIds, good.Id)
+		names = append(names, good.Name)
+		descriptions = append(descriptions, good.Description)
+		priorities = append(priorities, priority)
+		createdAt = append(createdAt, good.CreatedAt)
+		priority++
+	}
+
+	if len(accepted) == 0 {
+		return outcomes, nil
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`INSERT INTO goods (
+        id,
+		project_id,
+    	name,
+        description,
+    	priority,
+        removed,
+    	created_at
+	)
+	SELECT id, $1, name, description, priority, false, created_at
+	FROM unnest($2::bigint[], $3::text[], $4::text[], $5::bigint[], $6::timestamp[])
+		AS new_goods(id, name, description, priority, created_at)
+	RETURNING `+goodColumns,
+		projectId,
+		newIds,
+		names,
+		descriptions,
+		priorities,
+		createdAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert goods: %w", err)
+	}
+
+	created, err := scanGoods(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert goods: %w", mapError(err))
+	}
+
+	byId := make(map[int64]Good, len(created))
+	for _, good := range created {
+		byId[good.Id] = good
+	}
+
+	for _, i := range accepted {
+		good := byId[goods[i].Id]
+		outcomes[i].Good = good
+
+		err = logEvent(ctx, tx, OperationCreate, nil, &good)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return outcomes, nil
+}
+
+// UpdateGoods applies the updates to the goods of the project one after
+// another in a single transaction, each like UpdateGood, so priorities stay
+// dense in every project involved. Updates of missing goods, moving goods to
+// missing or archived projects and other rejected updates fail individually
+// without affecting the rest.
+func (s PgStore) UpdateGoods(ctx context.Context, projectId int64, items []BatchUpdateItem) ([]BatchOutcome, error) {
+	outcomes := make([]BatchOutcome, len(items))
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	projectIds := []int64{projectId}
+	for _, item := range items {
+		projectIds = append(projectIds, item.ProjectId)
+	}
+
+	// lock in a fixed order, so concurrent moves between projects can't
+	// deadlock
+	for _, lockId := range sortedUnique(projectIds...) {
+		err = lockPriorities(ctx, tx, lockId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var updatedIds []int64
+	for i, item := range items {
+		if slices.Contains(updatedIds, item.Id) {
+			outcomes[i].Err = ErrDuplicateInBatch
+			continue
+		}
+
+		// a savepoint keeps a rejected update from aborting the whole batch
+		savepoint, err := tx.Begin(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to begin savepoint: %w", err)
+		}
+
+		_, err = updateGood(ctx, savepoint, item.Id, projectId, item.patch())
+		var appErr *apperror.Error
+		if errors.As(err, &appErr) {
+			outcomes[i].Err = err
+			if err = savepoint.Rollback(ctx); err != nil {
+				return nil, fmt.Errorf("failed to roll back savepoint: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err = savepoint.Commit(ctx); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+		updatedIds = append(updatedIds, item.Id)
+	}
+
+	if len(updatedIds) == 0 {
+		return outcomes, nil
+	}
+
+	// later updates may have shifted the goods updated before them
+	rows, err := tx.Query(ctx, `SELECT `+goodColumns+` FROM goods WHERE id = ANY($1)`, updatedIds)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select updated goods: %w", err)
+	}
+
+	updated, err := scanGoods(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select updated goods: %w", err)
+	}
+
+	byId := make(map[int64]Good, len(updated))
+	for _, good := range updated {
+		byId[good.Id] = good
+	}
+
+	for i, item := range items {
+		if outcomes[i].Err == nil {
+			outcomes[i].Good = byId[item.Id]
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return outcomes, nil
+}
+
+// DeleteGoods soft-deletes the goods of the project in a single statement and
+// closes the gaps they leave in its priorities. Missing goods are rejected
+// individually, already removed ones are left as they are.
+func (s PgStore) DeleteGoods(ctx context.Context, projectId int64, ids []int64) ([]BatchOutcome, error) {
+	outcomes := make([]BatchOutcome, len(ids))
+
+	tx, err := s.Pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	err = lockPriorities(ctx, tx, projectId)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := lockProjectGoods(ctx, tx, projectId, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var accepted []int
+	var removing []int64
+	for i, id := range ids {
+		good, ok := existing[id]
+		switch {
+		case !ok:
+			outcomes[i].Err = ErrNotFound
+		case slices.Contains(ids[:i], id):
+			outcomes[i].Err = ErrDuplicateInBatch
+		case good.Removed:
+			outcomes[i].Good = good
+		default:
+			accepted = append(accepted, i)
+			removing = append(removing, id)
+		}
+	}
+
+	if len(accepted) == 0 {
+		return outcomes, nil
+	}
+
+	rows, err := tx.Query(
+		ctx,
+		`UPDATE goods 
+	SET removed = true, removed_at = now(), version = version + 1
+	WHERE project_id = $1 AND id = ANY($2)
+	RETURNING `+goodColumns,
+		projectId,
+		removing,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update goods: %w", err)
+	}
+
+	removed, err := scanGoods(rows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update goods: %w", err)
+	}
+
+	byId := make(map[int64]Good, len(removed))
+	for _, good := range removed {
+		byId[good.Id] = good
+	}
+
+	vacated := make([]int64, 0, len(accepted))
+	for _, i := range accepted {
+		before := existing[ids[i]]
+		good := byId[ids[i]]
+		outcomes[i].Good = good
+		vacated = append(vacated, before.Priority)
+
+		err = logEvent(ctx, tx, OperationDelete, &before, &good)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = closePriorityGaps(ctx, tx, projectId, vacated)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	return outcomes, nil
 }
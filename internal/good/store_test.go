@@ -0,0 +1,531 @@
+package good
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"gogogo/internal/request"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// testDatabaseDSNEnv names the variable holding the DSN of a migrated Postgres
+// database to run the store suite against, PgStore is skipped without it.
+const testDatabaseDSNEnv = "GOODS_TEST_DATABASE_DSN"
+
+// missingProjectId is a project id no test database is expected to reach.
+const missingProjectId = 2_000_000_000
+
+// storeFixture is a Store under test together with the means to set up the
+// projects its goods belong to.
+type storeFixture struct {
+	store          Store
+	addProject     func(t *testing.T) int64
+	archiveProject func(t *testing.T, id int64)
+}
+
+// memStoreFixtures returns fresh stores, so tests don't see each other's goods.
+func memStoreFixtures(t *testing.T) func(t *testing.T) storeFixture {
+	return func(t *testing.T) storeFixture {
+		s := NewMemStore()
+		var lastId int64
+
+		return storeFixture{
+			store: s,
+			addProject: func(t *testing.T) int64 {
+				lastId++
+				s.AddProject(lastId)
+				return lastId
+			},
+			archiveProject: func(t *testing.T, id int64) {
+				s.ArchiveProject(id)
+			},
+		}
+	}
+}
+
+// pgStoreFixtures share the database, tests work on fresh projects so they
+// don't see each other's goods.
+func pgStoreFixtures(t *testing.T) func(t *testing.T) storeFixture {
+	dsn := os.Getenv(testDatabaseDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDatabaseDSNEnv)
+	}
+
+	pool, err := pgxpool.Connect(context.Background(), dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	fixture := storeFixture{
+		store: NewStore(pool),
+		addProject: func(t *testing.T) int64 {
+			var id int64
+			err := pool.QueryRow(context.Background(), `INSERT INTO projects (name) VALUES ('test') RETURNING id`).Scan(&id)
+			if err != nil {
+				t.Fatalf("add project: %v", err)
+			}
+			return id
+		},
+		archiveProject: func(t *testing.T, id int64) {
+			_, err := pool.Exec(context.Background(), `UPDATE projects SET archived = true WHERE id = $1`, id)
+			if err != nil {
+				t.Fatalf("archive project: %v", err)
+			}
+		},
+	}
+
+	return func(t *testing.T) storeFixture {
+		return fixture
+	}
+}
+
+var storeFixtures = []struct {
+	name string
+	// setup prepares the implementation once and returns the fixture for
+	// each test.
+	setup func(t *testing.T) func(t *testing.T) storeFixture
+}{
+	{"MemStore", memStoreFixtures},
+	{"PgStore", pgStoreFixtures},
+}
+
+// TestStoreConformance runs every case against each Store implementation, they
+// must behave the same.
+func TestStoreConformance(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, f storeFixture)
+	}{
+		{"create assigns priorities per project", testCreateAssignsPriorities},
+		{"create rejects missing and archived projects", testCreateRejectsProjects},
+		{"batch create generates ids and places goods last", testBatchCreate},
+		{"delete removes softly and closes the gap", testDeleteClosesGap},
+		{"restore places the good last", testRestorePlacesLast},
+		{"removed goods of archived projects stay removed", testArchivedProjectKeepsRemoved},
+		{"reprioritize moves the good and renumbers", testReprioritize},
+		{"update moves goods between projects", testUpdateMovesBetweenProjects},
+		{"update repositions and rejects priority of removed goods", testUpdatePriority},
+		{"batch update keeps priorities dense", testBatchUpdateKeepsPrioritiesDense},
+		{"stale versions are rejected", testStaleVersions},
+		{"patches changing nothing are not written", testNoopUpdate},
+		{"missing goods are not found", testNotFound},
+	}
+
+	for _, fixture := range storeFixtures {
+		t.Run(fixture.name, func(t *testing.T) {
+			newFixture := fixture.setup(t)
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					tt.run(t, newFixture(t))
+				})
+			}
+		})
+	}
+}
+
+func testCreateAssignsPriorities(t *testing.T, f storeFixture) {
+	first, second := f.addProject(t), f.addProject(t)
+
+	a := mustCreate(t, f, first, "a")
+	b := mustCreate(t, f, first, "b")
+	c := mustCreate(t, f, second, "c")
+	d := mustCreate(t, f, first, "d")
+
+	assertPriorities(t, f, first, a.Id, b.Id, d.Id)
+	assertPriorities(t, f, second, c.Id)
+
+	if a.Id == b.Id || a.Version != 1 {
+		t.Errorf("created goods %+v and %+v, want distinct ids at version 1", a, b)
+	}
+}
+
+func testCreateRejectsProjects(t *testing.T, f storeFixture) {
+	_, err := f.store.CreateGood(context.Background(), Good{ProjectId: missingProjectId, Name: "a"})
+	assertErr(t, err, ErrProjectNotFound)
+
+	projectId := f.addProject(t)
+	f.archiveProject(t, projectId)
+	_, err = f.store.CreateGood(context.Background(), Good{ProjectId: projectId, Name: "a"})
+	assertErr(t, err, ErrProjectArchived)
+
+	_, err = f.store.CreateGoods(context.Background(), projectId, []Good{{Name: "a"}})
+	assertErr(t, err, ErrProjectArchived)
+}
+
+func testBatchCreate(t *testing.T, f storeFixture) {
+	projectId := f.addProject(t)
+	first := mustCreate(t, f, projectId, "first")
+
+	outcomes, err := f.store.CreateGoods(context.Background(), projectId, []Good{
+		{Name: "a", CreatedAt: time.Now()},
+		{Name: "b", CreatedAt: time.Now()},
+		{Id: first.Id, Name: "taken", CreatedAt: time.Now()},
+		{Name: "c", CreatedAt: time.Now()},
+	})
+	if err != nil {
+		t.Fatalf("create goods: %v", err)
+	}
+
+	assertErr(t, outcomes[2].Err, ErrAlreadyExists)
+
+	var ids []int64
+	for _, i := range []int{0, 1, 3} {
+		if outcomes[i].Err != nil {
+			t.Fatalf("good %d: %v", i, outcomes[i].Err)
+		}
+		ids = append(ids, outcomes[i].Good.Id)
+	}
+
+	assertPriorities(t, f, projectId, first.Id, ids[0], ids[1], ids[2])
+}
+
+func testDeleteClosesGap(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+	b := mustCreate(t, f, projectId, "b")
+	c := mustCreate(t, f, projectId, "c")
+
+	deleted, err := f.store.DeleteGood(ctx, b.Id, projectId, 0)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if !deleted.Removed || deleted.RemovedAt == nil {
+		t.Errorf("deleted good %+v, want it removed with removedAt set", deleted)
+	}
+
+	assertPriorities(t, f, projectId, a.Id, c.Id)
+
+	// the good is kept, only hidden from the active ones
+	got, err := f.store.GetGood(ctx, b.Id, projectId)
+	if err != nil || !got.Removed {
+		t.Errorf("get deleted good = %+v, %v, want it removed", got, err)
+	}
+
+	again, err := f.store.DeleteGood(ctx, b.Id, projectId, 0)
+	if err != nil || again.Version != deleted.Version {
+		t.Errorf("delete again = %+v, %v, want a no-op", again, err)
+	}
+}
+
+func testRestorePlacesLast(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+	b := mustCreate(t, f, projectId, "b")
+	c := mustCreate(t, f, projectId, "c")
+
+	_, err := f.store.RestoreGood(ctx, a.Id, projectId)
+	assertErr(t, err, ErrNotRemoved)
+
+	if _, err = f.store.DeleteGood(ctx, a.Id, projectId, 0); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	restored, err := f.store.RestoreGood(ctx, a.Id, projectId)
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if restored.Removed || restored.RemovedAt != nil || restored.Priority != 3 {
+		t.Errorf("restored good %+v, want it active at priority 3", restored)
+	}
+
+	assertPriorities(t, f, projectId, b.Id, c.Id, a.Id)
+}
+
+func testArchivedProjectKeepsRemoved(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+	b := mustCreate(t, f, projectId, "b")
+
+	for _, id := range []int64{a.Id, b.Id} {
+		if _, err := f.store.DeleteGood(ctx, id, projectId, 0); err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+	}
+	f.archiveProject(t, projectId)
+
+	_, err := f.store.RestoreGood(ctx, a.Id, projectId)
+	assertErr(t, err, ErrProjectArchived)
+
+	_, err = f.store.UpdateGood(ctx, b.Id, projectId, GoodPatch{
+		Removed: request.PatchField[bool]{Set: true, Value: false},
+	})
+	assertErr(t, err, ErrProjectArchived)
+
+	assertPriorities(t, f, projectId)
+}
+
+func testReprioritize(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+	b := mustCreate(t, f, projectId, "b")
+	c := mustCreate(t, f, projectId, "c")
+	d := mustCreate(t, f, projectId, "d")
+
+	changed, err := f.store.ReprioritizeGood(ctx, c.Id, projectId, ReprioritizeGoodParams{NewPriority: 1})
+	if err != nil {
+		t.Fatalf("reprioritize: %v", err)
+	}
+
+	want := []ReprioritizedGood{{Id: c.Id, Priority: 1}, {Id: a.Id, Priority: 2}, {Id: b.Id, Priority: 3}}
+	if len(changed) != len(want) {
+		t.Fatalf("changed %+v, want %+v", changed, want)
+	}
+	for i := range want {
+		if changed[i].Id != want[i].Id || changed[i].Priority != want[i].Priority {
+			t.Errorf("changed[%d] = %+v, want %+v", i, changed[i], want[i])
+		}
+	}
+	assertPriorities(t, f, projectId, c.Id, a.Id, b.Id, d.Id)
+
+	// positions past the end are clamped to the last one
+	if _, err = f.store.ReprioritizeGood(ctx, c.Id, projectId, ReprioritizeGoodParams{NewPriority: 100}); err != nil {
+		t.Fatalf("reprioritize: %v", err)
+	}
+	assertPriorities(t, f, projectId, a.Id, b.Id, d.Id, c.Id)
+
+	unchanged, err := f.store.ReprioritizeGood(ctx, c.Id, projectId, ReprioritizeGoodParams{NewPriority: 4})
+	if err != nil || len(unchanged) != 0 {
+		t.Errorf("reprioritize in place = %+v, %v, want no changes", unchanged, err)
+	}
+}
+
+func testUpdateMovesBetweenProjects(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	source, target := f.addProject(t), f.addProject(t)
+	a := mustCreate(t, f, source, "a")
+	b := mustCreate(t, f, source, "b")
+	c := mustCreate(t, f, source, "c")
+	d := mustCreate(t, f, target, "d")
+
+	moved, err := f.store.UpdateGood(ctx, a.Id, source, GoodPatch{
+		ProjectId: request.PatchField[int64]{Set: true, Value: target},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if moved.ProjectId != target || moved.Name != a.Name {
+		t.Errorf("moved good %+v, want it in project %d with its name kept", moved, target)
+	}
+
+	assertPriorities(t, f, source, b.Id, c.Id)
+	assertPriorities(t, f, target, d.Id, a.Id)
+
+	_, err = f.store.UpdateGood(ctx, b.Id, source, GoodPatch{
+		ProjectId: request.PatchField[int64]{Set: true, Value: missingProjectId},
+	})
+	assertErr(t, err, ErrProjectNotFound)
+
+	f.archiveProject(t, target)
+	_, err = f.store.UpdateGood(ctx, b.Id, source, GoodPatch{
+		ProjectId: request.PatchField[int64]{Set: true, Value: target},
+	})
+	assertErr(t, err, ErrProjectArchived)
+}
+
+func testUpdatePriority(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+	b := mustCreate(t, f, projectId, "b")
+	c := mustCreate(t, f, projectId, "c")
+
+	updated, err := f.store.UpdateGood(ctx, c.Id, projectId, GoodPatch{
+		Priority:    request.PatchField[int64]{Set: true, Value: 1},
+		Description: request.PatchField[string]{Set: true, Null: true},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Priority != 1 || updated.Description != "" {
+		t.Errorf("updated good %+v, want it first with the description cleared", updated)
+	}
+	assertPriorities(t, f, projectId, c.Id, a.Id, b.Id)
+
+	_, err = f.store.UpdateGood(ctx, a.Id, projectId, GoodPatch{
+		Removed:  request.PatchField[bool]{Set: true, Value: true},
+		Priority: request.PatchField[int64]{Set: true, Value: 1},
+	})
+	assertErr(t, err, ErrRemovedPriority)
+
+	_, err = f.store.UpdateGood(ctx, a.Id, projectId, GoodPatch{
+		Removed: request.PatchField[bool]{Set: true, Value: true},
+	})
+	if err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	assertPriorities(t, f, projectId, c.Id, b.Id)
+}
+
+func testBatchUpdateKeepsPrioritiesDense(t *testing.T, f storeFixture) {
+	source, target := f.addProject(t), f.addProject(t)
+	a := mustCreate(t, f, source, "a")
+	b := mustCreate(t, f, source, "b")
+	c := mustCreate(t, f, source, "c")
+	d := mustCreate(t, f, target, "d")
+
+	active, removed := false, true
+	outcomes, err := f.store.UpdateGoods(context.Background(), source, []BatchUpdateItem{
+		{Id: c.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: source, Name: "c", Priority: 1, Removed: &active}},
+		{Id: a.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: target, Name: "a", Priority: 1, Removed: &active}},
+		{Id: c.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: source, Name: "c", Removed: &active}},
+		{Id: b.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: source, Name: "b", Removed: &removed}},
+		{Id: d.Id, UpdateGoodParams: UpdateGoodParams{ProjectId: source, Name: "d", Removed: &active}},
+	})
+	if err != nil {
+		t.Fatalf("update goods: %v", err)
+	}
+
+	assertErr(t, outcomes[2].Err, ErrDuplicateInBatch)
+	// d belongs to the other project
+	assertErr(t, outcomes[4].Err, ErrNotFound)
+	for _, i := range []int{0, 1, 3} {
+		if outcomes[i].Err != nil {
+			t.Fatalf("item %d: %v", i, outcomes[i].Err)
+		}
+	}
+
+	// outcomes reflect the shifts caused by later items
+	if outcomes[0].Good.Priority != 1 || outcomes[1].Good.Priority != 1 {
+		t.Errorf("outcomes %+v and %+v, want both first in their projects", outcomes[0].Good, outcomes[1].Good)
+	}
+
+	assertPriorities(t, f, source, c.Id)
+	assertPriorities(t, f, target, a.Id, d.Id)
+}
+
+func testStaleVersions(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+	mustCreate(t, f, projectId, "b")
+
+	updated, err := f.store.UpdateGood(ctx, a.Id, projectId, GoodPatch{
+		Name:    request.PatchField[string]{Set: true, Value: "renamed"},
+		Version: a.Version,
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if updated.Version <= a.Version {
+		t.Errorf("updated version %d, want it past %d", updated.Version, a.Version)
+	}
+
+	_, err = f.store.UpdateGood(ctx, a.Id, projectId, GoodPatch{
+		Name:    request.PatchField[string]{Set: true, Value: "stale"},
+		Version: a.Version,
+	})
+	assertErr(t, err, ErrPreconditionFailed)
+
+	_, err = f.store.ReprioritizeGood(ctx, a.Id, projectId, ReprioritizeGoodParams{NewPriority: 2, Version: a.Version})
+	assertErr(t, err, ErrPreconditionFailed)
+
+	_, err = f.store.DeleteGood(ctx, a.Id, projectId, a.Version)
+	assertErr(t, err, ErrPreconditionFailed)
+}
+
+func testNoopUpdate(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId := f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+
+	for _, patch := range []GoodPatch{
+		{},
+		{Name: request.PatchField[string]{Set: true, Value: a.Name}, Version: a.Version},
+		{ProjectId: request.PatchField[int64]{Set: true, Value: projectId}},
+	} {
+		got, err := f.store.UpdateGood(ctx, a.Id, projectId, patch)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if got.Version != a.Version || got.Name != a.Name {
+			t.Errorf("update with %+v = %+v, want the good unchanged at version %d", patch, got, a.Version)
+		}
+	}
+
+	_, err := f.store.UpdateGood(ctx, a.Id, projectId, GoodPatch{Version: a.Version + 1})
+	assertErr(t, err, ErrPreconditionFailed)
+}
+
+func testNotFound(t *testing.T, f storeFixture) {
+	ctx := context.Background()
+	projectId, other := f.addProject(t), f.addProject(t)
+	a := mustCreate(t, f, projectId, "a")
+
+	// the good exists, but not in the other project
+	for _, id := range []int64{a.Id + 1_000_000, a.Id} {
+		_, err := f.store.GetGood(ctx, id, other)
+		assertErr(t, err, ErrNotFound)
+
+		_, err = f.store.DeleteGood(ctx, id, other, 0)
+		assertErr(t, err, ErrNotFound)
+
+		_, err = f.store.RestoreGood(ctx, id, other)
+		assertErr(t, err, ErrNotFound)
+
+		_, err = f.store.UpdateGood(ctx, id, other, GoodPatch{
+			Name: request.PatchField[string]{Set: true, Value: "b"},
+		})
+		assertErr(t, err, ErrNotFound)
+
+		_, err = f.store.ReprioritizeGood(ctx, id, other, ReprioritizeGoodParams{NewPriority: 1})
+		assertErr(t, err, ErrNotFound)
+	}
+}
+
+func mustCreate(t *testing.T, f storeFixture, projectId int64, name string) Good {
+	t.Helper()
+
+	good, err := f.store.CreateGood(context.Background(), Good{
+		ProjectId: projectId,
+		Name:      name,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("create good: %v", err)
+	}
+
+	return good
+}
+
+// assertPriorities checks that the active goods of the project are exactly ids,
+// at priorities 1, 2 and so on in the given order.
+func assertPriorities(t *testing.T, f storeFixture, projectId int64, ids ...int64) {
+	t.Helper()
+
+	active := false
+	goods, err := f.store.ListGoods(context.Background(), ListGoodsParams{
+		GoodsFilter: GoodsFilter{ProjectId: projectId, Removed: &active},
+		SortBy:      SortByPriority,
+		Limit:       1000,
+	})
+	if err != nil {
+		t.Fatalf("list goods: %v", err)
+	}
+
+	if len(goods) != len(ids) {
+		t.Fatalf("project %d has %d active goods, want %d: %+v", projectId, len(goods), len(ids), goods)
+	}
+	for i, good := range goods {
+		if good.Id != ids[i] || good.Priority != int64(i+1) {
+			t.Errorf("project %d position %d holds good %d at priority %d, want good %d at priority %d",
+				projectId, i, good.Id, good.Priority, ids[i], i+1)
+		}
+	}
+}
+
+func assertErr(t *testing.T, err, want error) {
+	t.Helper()
+
+	if !errors.Is(err, want) {
+		t.Errorf("err = %v, want %v", err, want)
+	}
+}
@@ -1,22 +1,114 @@
 package good
 
-import "time"
+import (
+	"time"
+
+	"gogogo/internal/apperror"
+	"gogogo/internal/request"
+)
 
 type QueryParams struct {
-	Id        int64 `schema:"id,required"`
-	ProjectId int64 `schema:"projectId,required"`
+	Id        int64 `schema:"id" validate:"required,min=1,max=2147483647"`
+	ProjectId int64 `schema:"projectId" validate:"required,min=1,max=2147483647"`
+}
+
+type DeleteGoodQuery struct {
+	QueryParams
+	// Version, when set, must match the good's current version.
+	Version int64 `schema:"version" validate:"min=0"`
+}
+
+type ProjectQueryParams struct {
+	ProjectId int64 `schema:"projectId" validate:"required,min=1,max=2147483647"`
+}
+
+type ListGoodsQuery struct {
+	ProjectId int64 `schema:"projectId" validate:"min=0,max=2147483647"`
+	// Include and Only select whether removed goods are listed alongside the
+	// active ones or instead of them, by default they are hidden.
+	Include string `schema:"include" validate:"oneof=removed"`
+	Only    string `schema:"only" validate:"oneof=removed"`
+	// Removed is the older form of the switch, it takes precedence when set.
+	Removed     *bool     `schema:"removed"`
+	CreatedFrom time.Time `schema:"createdFrom"`
+	CreatedTo   time.Time `schema:"createdTo"`
+	Search      string    `schema:"q" validate:"max=255"`
+	Sort        SortField `schema:"sort" validate:"oneof=id priority name createdAt"`
+	Order       string    `schema:"order" validate:"oneof=asc desc"`
+	Limit       int64     `schema:"limit" validate:"min=0,max=1000"`
+	Offset      int64     `schema:"offset" validate:"min=0"`
+	After       string    `schema:"after"`
+}
+
+// removedFilter resolves the removed goods switches into GoodsFilter.Removed.
+func (q ListGoodsQuery) removedFilter() (*bool, error) {
+	if q.Include != "" && q.Only != "" {
+		return nil, ErrValidation.WithDetails(map[string]string{
+			"include": "cannot be combined with only",
+		})
+	}
+
+	switch {
+	case q.Removed != nil:
+		return q.Removed, nil
+	case q.Include != "":
+		return nil, nil
+	case q.Only != "":
+		removed := true
+		return &removed, nil
+	}
+
+	removed := false
+	return &removed, nil
+}
+
+// GoodsFilter narrows the set of goods that are listed and counted.
+// Zero values mean "no restriction".
+type GoodsFilter struct {
+	ProjectId   int64
+	Removed     *bool
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+	// Search is matched as a case-insensitive substring of name or description.
+	Search string
+}
+
+// SortField is a column goods can be ordered by in listings.
+type SortField string
+
+const (
+	SortById        SortField = "id"
+	SortByPriority  SortField = "priority"
+	SortByName      SortField = "name"
+	SortByCreatedAt SortField = "createdAt"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortById, SortByPriority, SortByName, SortByCreatedAt:
+		return true
+	}
+
+	return false
 }
 
 type ListGoodsParams struct {
-	Limit  int64
+	GoodsFilter
+	SortBy   SortField
+	SortDesc bool
+	Limit    int64
+	// After switches to keyset pagination, Offset is ignored when it is set.
+	After  *Cursor
 	Offset int64
 }
 
 type ListGoodsMeta struct {
-	Total   int64 `json:"total"`
-	Removed int64 `json:"removed"`
-	Limit   int64 `json:"limit"`
-	Offset  int64 `json:"offset"`
+	ProjectId  int64  `json:"projectId,omitempty"`
+	Total      int64  `json:"total"`
+	Removed    int64  `json:"removed"`
+	Limit      int64  `json:"limit"`
+	Offset     int64  `json:"offset"`
+	NextCursor string `json:"nextCursor,omitempty"`
 }
 
 type ListGoodsResponse struct {
@@ -25,26 +117,157 @@ type ListGoodsResponse struct {
 }
 
 type CreateGoodParams struct {
-	Id          int64  `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	ProjectId   int64
+	// Id is generated unless the client opts into choosing it.
+	Id          int64  `json:"id" validate:"min=0,max=2147483647"`
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=255"`
+	ProjectId   int64  `json:"-"`
 }
 
 type UpdateGoodParams struct {
-	ProjectId   int64     `json:"projectId"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Priority    int64     `json:"priority"`
-	Removed     *bool     `json:"removed"`
-	CreatedAt   time.Time `json:"createdAt"`
+	ProjectId   int64  `json:"projectId" validate:"required,min=1,max=2147483647"`
+	Name        string `json:"name" validate:"required,max=255"`
+	Description string `json:"description" validate:"max=255"`
+	// Priority, when set, moves the good to that position among the active
+	// goods of its project, like ReprioritizeGood does.
+	Priority  int64     `json:"priority" validate:"min=0,max=2147483647"`
+	Removed   *bool     `json:"removed" validate:"required"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Version, when set, must match the good's current version.
+	Version int64 `json:"version" validate:"min=0"`
+}
+
+// patch expresses the update as a merge patch, so that it moves goods between
+// priorities the way a single good update does. An empty description and a
+// zero priority leave the good's ones unchanged.
+func (p UpdateGoodParams) patch() GoodPatch {
+	patch := GoodPatch{
+		ProjectId: request.PatchField[int64]{Set: true, Value: p.ProjectId},
+		Name:      request.PatchField[string]{Set: true, Value: p.Name},
+		Version:   p.Version,
+	}
+	if p.Description != "" {
+		patch.Description = request.PatchField[string]{Set: true, Value: p.Description}
+	}
+	if p.Priority != 0 {
+		patch.Priority = request.PatchField[int64]{Set: true, Value: p.Priority}
+	}
+	if p.Removed != nil {
+		patch.Removed = request.PatchField[bool]{Set: true, Value: *p.Removed}
+	}
+
+	return patch
+}
+
+// GoodPatch is a JSON merge patch (RFC 7396) of a good. Only the fields present
+// in the patch change, an explicit null clears the description and is rejected
+// for the other fields.
+type GoodPatch struct {
+	ProjectId   request.PatchField[int64]  `json:"projectId" validate:"required,min=1,max=2147483647"`
+	Name        request.PatchField[string] `json:"name" validate:"required,min=1,max=255"`
+	Description request.PatchField[string] `json:"description" validate:"max=255"`
+	Priority    request.PatchField[int64]  `json:"priority" validate:"required,min=1,max=2147483647"`
+	Removed     request.PatchField[bool]   `json:"removed" validate:"required"`
+	// Version, when set, must match the good's current version.
+	Version int64 `json:"version" validate:"min=0"`
+}
+
+// apply returns the good with the patched fields changed. The priority is left
+// to the caller, as changing it moves other goods as well.
+func (p GoodPatch) apply(good Good) Good {
+	if p.ProjectId.Set {
+		good.ProjectId = p.ProjectId.Value
+	}
+	if p.Name.Set {
+		good.Name = p.Name.Value
+	}
+	if p.Description.Set {
+		// the zero value is what a good created without a description has
+		good.Description = p.Description.Value
+	}
+	if p.Removed.Set {
+		good.Removed = p.Removed.Value
+	}
+
+	return good
 }
 
 type ReprioritizeGoodParams struct {
-	NewPriority int64 `json:"newPriority"`
+	NewPriority int64 `json:"newPriority" validate:"required,min=1,max=2147483647"`
+	// Version, when set, must match the moved good's current version.
+	Version int64 `json:"version" validate:"min=0"`
 }
 
 type ReprioritizedGood struct {
 	Id       int64 `json:"id"`
 	Priority int64 `json:"priority"`
+	Version  int64 `json:"version"`
+}
+
+type BatchCreateRequest struct {
+	Goods []CreateGoodParams `json:"goods" validate:"required,max=1000"`
+}
+
+type BatchUpdateItem struct {
+	Id int64 `json:"id" validate:"required,min=1,max=2147483647"`
+	UpdateGoodParams
+}
+
+type BatchUpdateRequest struct {
+	Goods []BatchUpdateItem `json:"goods" validate:"required,max=1000"`
+}
+
+type BatchDeleteRequest struct {
+	Ids []int64 `json:"ids" validate:"required,max=1000"`
+}
+
+// batchDeleteItem wraps an id of a batch delete, so it is validated like the
+// items of the other batches.
+type batchDeleteItem struct {
+	Id int64 `json:"id" validate:"required,min=1,max=2147483647"`
+}
+
+// BatchOutcome is the result of a single item of a batch, Err is set when the
+// item was rejected while the rest of the batch went through.
+type BatchOutcome struct {
+	Good Good
+	Err  error
+}
+
+type BatchResult struct {
+	Index int                     `json:"index"`
+	Good  *Good                   `json:"good,omitempty"`
+	Error *apperror.ErrorResponse `json:"error,omitempty"`
+}
+
+type BatchResponse struct {
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Results   []BatchResult `json:"results"`
+}
+
+type HistoryQuery struct {
+	ProjectId int64 `schema:"projectId" validate:"required,min=1,max=2147483647"`
+	Limit     int64 `schema:"limit" validate:"min=0,max=1000"`
+	Offset    int64 `schema:"offset" validate:"min=0"`
+}
+
+type GoodHistoryQuery struct {
+	Id int64 `schema:"id" validate:"required,min=1,max=2147483647"`
+	HistoryQuery
+}
+
+// HistoryParams selects the events of a project, or of a single good when
+// GoodId is set.
+type HistoryParams struct {
+	ProjectId int64
+	GoodId    int64
+	Limit     int64
+	Offset    int64
+}
+
+type HistoryResponse struct {
+	Limit  int64   `json:"limit"`
+	Offset int64   `json:"offset"`
+	Events []Event `json:"events"`
 }
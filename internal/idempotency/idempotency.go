@@ -0,0 +1,250 @@
+// Package idempotency makes retries of mutating requests safe: the response to
+// a request carrying an Idempotency-Key is stored and replayed for later
+// requests with the same key instead of applying the change again.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"gogogo/internal/actor"
+	"gogogo/internal/apperror"
+	"gogogo/internal/request"
+	"gogogo/pkg/cache"
+)
+
+// KeyHeader is the request header clients pass the idempotency key in.
+const KeyHeader = "Idempotency-Key"
+
+// ReplayedHeader marks responses that were replayed rather than produced anew.
+const ReplayedHeader = "Idempotent-Replayed"
+
+const MaxKeyLength = 255
+
+const KeyReusedErrorCode = apperror.IdempotencyKeyReusedErrorCode
+const KeyReusedErrorMessage = "errors.idempotency.keyReused"
+const InProgressErrorCode = apperror.IdempotencyInProgressErrorCode
+const InProgressErrorMessage = "errors.idempotency.inProgress"
+
+var (
+	ErrKeyReused = apperror.Validation(KeyReusedErrorCode, KeyReusedErrorMessage).
+			WithDetails(map[string]string{KeyHeader: "was already used for a different request"})
+	ErrInProgress = apperror.Conflict(InProgressErrorCode, InProgressErrorMessage).
+			WithDetails(map[string]string{KeyHeader: "a request with this key is still being processed"})
+	ErrKeyTooLong = request.ErrMalformedInput.
+			WithDetails(map[string]string{KeyHeader: fmt.Sprintf("must be at most %d characters", MaxKeyLength)})
+)
+
+const keyPrefix = "idempotency"
+
+type Config struct {
+	// TTL is how long responses are kept for replay.
+	TTL time.Duration
+	// LockTTL bounds how long a request holds its key, it must outlast the
+	// slowest request.
+	LockTTL time.Duration
+	// CacheTimeout bounds every cache operation.
+	CacheTimeout time.Duration
+}
+
+// record is a stored response together with the fingerprint of the request
+// that produced it.
+type record struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// Middleware stores the responses of mutating requests carrying a KeyHeader
+// in c and replays them for requests with the same key and actor. Reusing a
+// key for a different request is rejected, so is a retry that arrives while
+// the first request is still in flight. Server errors aren't stored, so such
+// requests can be retried. When the cache is unavailable requests are served
+// without idempotency. Responses evicted from c before their TTL can't be
+// replayed, so c should not be shared with an LRU cache of other data.
+func Middleware(c cache.Cache, cfg Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(KeyHeader)
+			if key == "" || !mutating(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > MaxKeyLength {
+				apperror.Write(w, ErrKeyTooLong)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				apperror.Write(w, request.ErrMalformedInput.WithDetails(map[string]string{"body": "can't be read"}))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			ctx := r.Context()
+			recordKey := fmt.Sprintf("%s:%s:%s", keyPrefix, actor.FromContext(ctx), key)
+			lockKey := recordKey + ":lock"
+			fingerprint := fingerprint(r, body)
+
+			stored, err := load(ctx, c, cfg, recordKey)
+			if err != nil {
+				log.Printf("idempotency: failed to load response: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if stored != nil {
+				replay(w, *stored, fingerprint)
+				return
+			}
+
+			var acquired bool
+			err = withTimeout(ctx, cfg, func(ctx context.Context) (err error) {
+				acquired, err = c.Add(ctx, lockKey, []byte(fingerprint), cfg.LockTTL)
+				return err
+			})
+			if err != nil {
+				log.Printf("idempotency: failed to lock key: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !acquired {
+				apperror.Write(w, ErrInProgress)
+				return
+			}
+			defer func() {
+				err := withTimeout(ctx, cfg, func(ctx context.Context) error {
+					return c.Delete(ctx, lockKey)
+				})
+				if err != nil {
+					log.Printf("idempotency: failed to unlock key: %v", err)
+				}
+			}()
+
+			// the first request may have completed between the lookup and the lock
+			stored, err = load(ctx, c, cfg, recordKey)
+			if err == nil && stored != nil {
+				replay(w, *stored, fingerprint)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+
+			data, err := json.Marshal(record{
+				Fingerprint: fingerprint,
+				Status:      recorder.status,
+				Header:      recorder.Header().Clone(),
+				Body:        recorder.body.Bytes(),
+			})
+			if err == nil {
+				err = withTimeout(ctx, cfg, func(ctx context.Context) error {
+					return c.Set(ctx, recordKey, data, cfg.TTL)
+				})
+			}
+			if err != nil {
+				log.Printf("idempotency: failed to store response: %v", err)
+			}
+		})
+	}
+}
+
+func mutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// fingerprint identifies the request a key was used for.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s %s?%s\n", r.Method, r.URL.Path, r.URL.RawQuery)
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// load returns the response stored under key, or nil if there is none.
+func load(ctx context.Context, c cache.Cache, cfg Config, key string) (*record, error) {
+	var data []byte
+	err := withTimeout(ctx, cfg, func(ctx context.Context) (err error) {
+		data, err = c.Get(ctx, key)
+		return err
+	})
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored record
+	err = json.Unmarshal(data, &stored)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stored, nil
+}
+
+// withTimeout runs the cache operation f bounded by the cache timeout.
+func withTimeout(ctx context.Context, cfg Config, f func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, cfg.CacheTimeout)
+	defer cancel()
+
+	return f(ctx)
+}
+
+func replay(w http.ResponseWriter, stored record, fingerprint string) {
+	if stored.Fingerprint != fingerprint {
+		apperror.Write(w, ErrKeyReused)
+		return
+	}
+
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.Status)
+	_, _ = w.Write(stored.Body)
+}
+
+// responseRecorder passes the response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,148 @@
+// Package ingest copies the audit events stored in the JetStream stream into
+// ClickHouse. It pulls through a durable consumer and acknowledges messages
+// only once they are inserted, so events published while ClickHouse is down
+// are delivered when it is back. Delivery is at-least-once, the logs table
+// collapses repeated events by their id.
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Fetcher pulls batches of messages, *nats.Subscription implements it.
+type Fetcher interface {
+	Fetch(batch int, opts ...nats.PullOpt) ([]*nats.Msg, error)
+}
+
+// Sink stores rows, each a single JSON object.
+type Sink interface {
+	Insert(ctx context.Context, rows [][]byte) error
+}
+
+type Config struct {
+	BatchSize int
+	// FetchTimeout bounds how long a pull waits for messages to arrive.
+	FetchTimeout time.Duration
+	// RetryDelay is how long a batch that failed to insert waits before it is
+	// delivered again.
+	RetryDelay time.Duration
+}
+
+type Ingester struct {
+	fetcher Fetcher
+	sink    Sink
+	cfg     Config
+}
+
+func NewIngester(fetcher Fetcher, sink Sink, cfg Config) *Ingester {
+	return &Ingester{
+		fetcher: fetcher,
+		sink:    sink,
+		cfg:     cfg,
+	}
+}
+
+// Run ingests messages until ctx is cancelled.
+func (i *Ingester) Run(ctx context.Context) {
+	for ctx.Err() == nil {
+		err := i.ingestBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("ingest: %v", err)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(i.cfg.RetryDelay):
+			}
+		}
+	}
+}
+
+// ingestBatch inserts the next batch of messages and acknowledges them. When
+// the insert fails the messages are handed back to be delivered again after
+// the retry delay.
+func (i *Ingester) ingestBatch(ctx context.Context) error {
+	msgs, err := i.fetcher.Fetch(i.cfg.BatchSize, nats.MaxWait(i.cfg.FetchTimeout))
+	if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch messages: %w", err)
+	}
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	rows := make([][]byte, 0, len(msgs))
+	for _, msg := range msgs {
+		rows = append(rows, msg.Data)
+	}
+
+	err = i.sink.Insert(ctx, rows)
+	if err != nil {
+		for _, msg := range msgs {
+			_ = msg.NakWithDelay(i.cfg.RetryDelay)
+		}
+		return fmt.Errorf("failed to insert %d events: %w", len(rows), err)
+	}
+
+	for _, msg := range msgs {
+		// an unacknowledged message is redelivered after the ack wait and
+		// collapsed with its first copy
+		if err := msg.Ack(); err != nil {
+			log.Printf("ingest: failed to ack message: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// ClickHouseSink inserts rows into a ClickHouse table over the HTTP interface.
+type ClickHouseSink struct {
+	URL    string
+	Table  string
+	Client *http.Client
+}
+
+func NewClickHouseSink(url, table string, client *http.Client) ClickHouseSink {
+	return ClickHouseSink{
+		URL:    url,
+		Table:  table,
+		Client: client,
+	}
+}
+
+func (s ClickHouseSink) Insert(ctx context.Context, rows [][]byte) error {
+	body := bytes.Join(rows, []byte("\n"))
+
+	values := url.Values{}
+	values.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", s.Table))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/?"+values.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	res, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to query clickhouse: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("clickhouse responded %d: %s", res.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
@@ -0,0 +1,239 @@
+// Package outbox implements the transactional outbox: messages are written to
+// the outbox table in the same transaction as the change they describe and a
+// Relay publishes them to the broker afterwards, retrying until delivered. This
+// gives at-least-once delivery, consumers must tolerate duplicates.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// Enqueue stores a message for subject as part of tx, it is published only if
+// tx commits.
+func Enqueue(ctx context.Context, tx pgx.Tx, subject string, payload []byte) error {
+	_, err := tx.Exec(
+		ctx,
+		`INSERT INTO outbox (subject, payload) VALUES ($1, $2)`,
+		subject,
+		payload,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue outbox message: %w", err)
+	}
+
+	return nil
+}
+
+// Publisher delivers a message to the broker. Messages with the same msgId
+// are retries of one another and may be deduplicated by the broker.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, data []byte, msgId string) error
+}
+
+type Config struct {
+	// PollInterval is how often the outbox is checked when it was found empty.
+	PollInterval time.Duration
+	BatchSize    int
+	// MaxBackoff caps the delay between attempts to deliver a failing message.
+	MaxBackoff time.Duration
+	// PublishTimeout bounds a single publish, including its acknowledgement.
+	PublishTimeout time.Duration
+	// Retention is how long delivered messages are kept before being pruned, 0
+	// keeps them forever.
+	Retention time.Duration
+	// PruneInterval is how often delivered messages are pruned.
+	PruneInterval time.Duration
+}
+
+type Relay struct {
+	pool      *pgxpool.Pool
+	publisher Publisher
+	cfg       Config
+}
+
+func NewRelay(pool *pgxpool.Pool, publisher Publisher, cfg Config) *Relay {
+	return &Relay{
+		pool:      pool,
+		publisher: publisher,
+		cfg:       cfg,
+	}
+}
+
+// Run relays messages until ctx is cancelled, pruning delivered ones every
+// prune interval.
+func (r *Relay) Run(ctx context.Context) {
+	var lastPrune time.Time
+	for {
+		if r.cfg.Retention > 0 && time.Since(lastPrune) >= r.cfg.PruneInterval {
+			lastPrune = time.Now()
+			if err := r.prune(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("outbox prune: %v", err)
+			}
+		}
+
+		n, err := r.relayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("outbox relay: %v", err)
+		}
+
+		// keep draining while full batches are delivered
+		if err == nil && n == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.cfg.PollInterval):
+		}
+	}
+}
+
+type message struct {
+	id       int64
+	subject  string
+	payload  []byte
+	attempts int
+}
+
+// relayBatch publishes the oldest due messages and returns how many were
+// delivered. Rows are locked with SKIP LOCKED so several relays can run side by
+// side without publishing the same message concurrently.
+func (r *Relay) relayBatch(ctx context.Context) (int, error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin tx: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rows, err := tx.Query(
+		ctx,
+		`SELECT id, subject, payload, attempts
+	FROM outbox
+	WHERE delivered_at IS NULL AND next_attempt_at <= current_timestamp
+	ORDER BY id
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED`,
+		r.cfg.BatchSize,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select outbox messages: %w", err)
+	}
+
+	var messages []message
+	for rows.Next() {
+		var m message
+		if err := rows.Scan(&m.id, &m.subject, &m.payload, &m.attempts); err != nil {
+			rows.Close()
+			return 0, err
+		}
+
+		messages = append(messages, m)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	var delivered []int64
+	var publishErr error
+	for _, m := range messages {
+		publishErr = r.publish(ctx, m)
+		if publishErr != nil {
+			// the broker is most likely unavailable, later messages would fail too
+			_, err = tx.Exec(
+				ctx,
+				`UPDATE outbox
+	SET attempts = attempts + 1, last_error = $1, next_attempt_at = current_timestamp + make_interval(secs => $2)
+	WHERE id = $3`,
+				publishErr.Error(),
+				r.backoff(m.attempts+1).Seconds(),
+				m.id,
+			)
+			if err != nil {
+				return 0, fmt.Errorf("failed to record outbox failure: %w", err)
+			}
+			break
+		}
+
+		delivered = append(delivered, m.id)
+	}
+
+	if len(delivered) > 0 {
+		_, err = tx.Exec(
+			ctx,
+			`UPDATE outbox
+	SET attempts = attempts + 1, delivered_at = current_timestamp
+	WHERE id = ANY($1)`,
+			delivered,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to mark outbox messages delivered: %w", err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit tx: %w", err)
+	}
+
+	if publishErr != nil {
+		return len(delivered), fmt.Errorf("failed to publish outbox message: %w", publishErr)
+	}
+
+	return len(delivered), nil
+}
+
+// prune deletes messages delivered longer than the retention period ago, a
+// batch at a time so no single statement holds locks for long.
+func (r *Relay) prune(ctx context.Context) error {
+	for {
+		tag, err := r.pool.Exec(
+			ctx,
+			`DELETE FROM outbox
+	WHERE id IN (
+		SELECT id
+		FROM outbox
+		WHERE delivered_at < current_timestamp - make_interval(secs => $1)
+		ORDER BY id
+		LIMIT $2
+	)`,
+			r.cfg.Retention.Seconds(),
+			r.cfg.BatchSize,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to delete delivered outbox messages: %w", err)
+		}
+
+		if tag.RowsAffected() < int64(r.cfg.BatchSize) {
+			return nil
+		}
+	}
+}
+
+func (r *Relay) publish(ctx context.Context, m message) error {
+	ctx, cancel := context.WithTimeout(ctx, r.cfg.PublishTimeout)
+	defer cancel()
+
+	return r.publisher.Publish(ctx, m.subject, m.payload, "outbox-"+strconv.FormatInt(m.id, 10))
+}
+
+// backoff doubles the delay with every failed attempt, starting from a second.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := time.Second
+	for i := 1; i < attempts && delay < r.cfg.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > r.cfg.MaxBackoff {
+		delay = r.cfg.MaxBackoff
+	}
+
+	return delay
+}
@@ -0,0 +1,8 @@
+package project
+
+import "gogogo/internal/apperror"
+
+const NotFoundErrorCode = apperror.ProjectNotFoundErrorCode
+const NotFoundErrorMessage = "errors.project.notFound"
+const ValidationErrorCode = apperror.ProjectValidationErrorCode
+const ValidationErrorMessage = "errors.project.validation"
@@ -0,0 +1,8 @@
+package project
+
+import "gogogo/internal/apperror"
+
+var (
+	ErrNotFound   = apperror.NotFound(NotFoundErrorCode, NotFoundErrorMessage)
+	ErrValidation = apperror.Validation(ValidationErrorCode, ValidationErrorMessage)
+)
@@ -0,0 +1,146 @@
+package project
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"gogogo/internal/apperror"
+
+	"github.com/gorilla/schema"
+)
+
+func init() {
+	decoder.IgnoreUnknownKeys(true)
+}
+
+var decoder = schema.NewDecoder()
+
+func ListProjects(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var params ListProjectsQuery
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		var limit int64 = 10
+		var offset int64 = 0
+		if params.Limit > 0 {
+			limit = params.Limit
+		}
+
+		if params.Offset > 0 {
+			offset = params.Offset
+		}
+
+		projects, err := s.ListProjects(r.Context(), ListProjectsParams{
+			Limit:  limit,
+			Offset: offset,
+		})
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to list projects: %w", err))
+			return
+		}
+
+		if projects == nil {
+			projects = make([]Project, 0)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(ListProjectsResponse{
+			Meta: ListProjectsMeta{
+				Total:    s.store.Count(r.Context()),
+				Archived: s.store.ArchivedCount(r.Context()),
+				Limit:    limit,
+				Offset:   offset,
+			},
+			Projects: projects,
+		})
+	}
+}
+
+func GetProject(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var params QueryParams
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		project, err := s.GetProject(r.Context(), params)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to get project: %w", err))
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(project)
+	}
+}
+
+func CreateProject(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var params CreateProjectParams
+		if err := decodeRequest(r, nil, &params); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		project, err := s.CreateProject(r.Context(), params)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to create project: %w", err))
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(project)
+	}
+}
+
+func RenameProject(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var queryParams QueryParams
+		var params RenameProjectParams
+		if err := decodeRequest(r, &queryParams, &params); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		project, err := s.RenameProject(r.Context(), queryParams.Id, params)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to rename project: %w", err))
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(project)
+	}
+}
+
+func ArchiveProject(s Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		var params QueryParams
+		if err := decodeRequest(r, &params, nil); err != nil {
+			apperror.Write(w, err)
+			return
+		}
+
+		project, err := s.ArchiveProject(r.Context(), params)
+		if err != nil {
+			apperror.Write(w, fmt.Errorf("failed to archive project: %w", err))
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(struct {
+			Id       int64 `json:"id"`
+			Archived bool  `json:"archived"`
+		}{
+			Id:       project.Id,
+			Archived: project.Archived,
+		})
+	}
+}
@@ -0,0 +1,11 @@
+package project
+
+import "time"
+
+// Project model
+type Project struct {
+	Id        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Archived  bool      `json:"archived"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,52 @@
+package project
+
+import (
+	"context"
+	"time"
+)
+
+type Store interface {
+	ListProjects(ctx context.Context, params ListProjectsParams) ([]Project, error)
+	GetProject(ctx context.Context, id int64) (Project, error)
+	CreateProject(ctx context.Context, project Project) (Project, error)
+	RenameProject(ctx context.Context, id int64, name string) (Project, error)
+	ArchiveProject(ctx context.Context, id int64) (Project, error)
+
+	Count(ctx context.Context) int64
+	ArchivedCount(ctx context.Context) int64
+}
+
+type Service struct {
+	store Store
+}
+
+func NewService(store Store) Service {
+	return Service{
+		store: store,
+	}
+}
+
+func (s Service) ListProjects(ctx context.Context, params ListProjectsParams) ([]Project, error) {
+	return s.store.ListProjects(ctx, params)
+}
+
+func (s Service) GetProject(ctx context.Context, params QueryParams) (Project, error) {
+	return s.store.GetProject(ctx, params.Id)
+}
+
+func (s Service) CreateProject(ctx context.Context, params CreateProjectParams) (Project, error) {
+	project := Project{
+		Name:      params.Name,
+		CreatedAt: time.Now(),
+	}
+
+	return s.store.CreateProject(ctx, project)
+}
+
+func (s Service) RenameProject(ctx context.Context, id int64, params RenameProjectParams) (Project, error) {
+	return s.store.RenameProject(ctx, id, params.Name)
+}
+
+func (s Service) ArchiveProject(ctx context.Context, params QueryParams) (Project, error) {
+	return s.store.ArchiveProject(ctx, params.Id)
+}
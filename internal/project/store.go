@@ -0,0 +1,185 @@
+package project
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type PgStore struct {
+	Pool *pgxpool.Pool
+}
+
+func NewStore(Pool *pgxpool.Pool) Store {
+	return PgStore{
+		Pool: Pool,
+	}
+}
+
+func (s PgStore) Count(ctx context.Context) int64 {
+	var count int64
+	row := s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM projects`)
+	err := row.Scan(&count)
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+func (s PgStore) ArchivedCount(ctx context.Context) int64 {
+	var count int64
+	row := s.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM projects WHERE archived = true`)
+	err := row.Scan(&count)
+	if err != nil {
+		return 0
+	}
+
+	return count
+}
+
+func (s PgStore) ListProjects(ctx context.Context, params ListProjectsParams) ([]Project, error) {
+	rows, err := s.Pool.Query(
+		ctx,
+		`SELECT 
+		id,
+		name,
+		archived,
+		created_at
+	FROM
+		projects
+	ORDER BY id
+	LIMIT $1 OFFSET $2`,
+		params.Limit,
+		params.Offset,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var projects []Project
+	for rows.Next() {
+		var project Project
+		if err := rows.Scan(
+			&project.Id,
+			&project.Name,
+			&project.Archived,
+			&project.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
+
+func (s PgStore) GetProject(ctx context.Context, id int64) (Project, error) {
+	row := s.Pool.QueryRow(
+		ctx,
+		`SELECT id, name, archived, created_at
+	FROM projects
+	WHERE id = $1`,
+		id,
+	)
+
+	var project Project
+	err := row.Scan(
+		&project.Id,
+		&project.Name,
+		&project.Archived,
+		&project.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Project{}, ErrNotFound
+		}
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+func (s PgStore) CreateProject(ctx context.Context, project Project) (Project, error) {
+	row := s.Pool.QueryRow(
+		ctx,
+		`INSERT INTO projects (name, created_at)
+	VALUES ($1, $2)
+	RETURNING id, name, archived, created_at`,
+		project.Name,
+		project.CreatedAt,
+	)
+
+	var created Project
+	err := row.Scan(
+		&created.Id,
+		&created.Name,
+		&created.Archived,
+		&created.CreatedAt,
+	)
+	if err != nil {
+		return Project{}, err
+	}
+
+	return created, nil
+}
+
+func (s PgStore) RenameProject(ctx context.Context, id int64, name string) (Project, error) {
+	row := s.Pool.QueryRow(
+		ctx,
+		`UPDATE projects
+	SET name = $1
+	WHERE id = $2
+	RETURNING id, name, archived, created_at`,
+		name,
+		id,
+	)
+
+	var project Project
+	err := row.Scan(
+		&project.Id,
+		&project.Name,
+		&project.Archived,
+		&project.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Project{}, ErrNotFound
+		}
+		return Project{}, err
+	}
+
+	return project, nil
+}
+
+func (s PgStore) ArchiveProject(ctx context.Context, id int64) (Project, error) {
+	row := s.Pool.QueryRow(
+		ctx,
+		`UPDATE projects
+	SET archived = true
+	WHERE id = $1
+	RETURNING id, name, archived, created_at`,
+		id,
+	)
+
+	var project Project
+	err := row.Scan(
+		&project.Id,
+		&project.Name,
+		&project.Archived,
+		&project.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Project{}, ErrNotFound
+		}
+		return Project{}, err
+	}
+
+	return project, nil
+}
@@ -0,0 +1,35 @@
+package project
+
+type QueryParams struct {
+	Id int64 `schema:"id" validate:"required,min=1,max=2147483647"`
+}
+
+type ListProjectsQuery struct {
+	Limit  int64 `schema:"limit" validate:"min=0,max=1000"`
+	Offset int64 `schema:"offset" validate:"min=0"`
+}
+
+type ListProjectsParams struct {
+	Limit  int64
+	Offset int64
+}
+
+type ListProjectsMeta struct {
+	Total    int64 `json:"total"`
+	Archived int64 `json:"archived"`
+	Limit    int64 `json:"limit"`
+	Offset   int64 `json:"offset"`
+}
+
+type ListProjectsResponse struct {
+	Meta     ListProjectsMeta `json:"meta"`
+	Projects []Project        `json:"projects"`
+}
+
+type CreateProjectParams struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
+
+type RenameProjectParams struct {
+	Name string `json:"name" validate:"required,max=255"`
+}
@@ -0,0 +1,34 @@
+package request
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// PatchField is a field of a JSON merge patch (RFC 7396). Set reports whether
+// the field was present in the patch and Null whether it was explicitly null,
+// Value holds the new value otherwise.
+type PatchField[T any] struct {
+	Set   bool
+	Null  bool
+	Value T
+}
+
+func (f *PatchField[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+	if bytes.Equal(data, []byte("null")) {
+		f.Null = true
+		return nil
+	}
+
+	return json.Unmarshal(data, &f.Value)
+}
+
+// Optional implements validate.Optional.
+func (f PatchField[T]) Optional() (any, bool) {
+	if f.Null {
+		return nil, f.Set
+	}
+
+	return f.Value, f.Set
+}
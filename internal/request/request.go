@@ -0,0 +1,68 @@
+package request
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gogogo/internal/apperror"
+	"gogogo/pkg/validate"
+
+	"github.com/gorilla/schema"
+)
+
+// ErrMalformedInput is returned when the query or body can't be decoded.
+var ErrMalformedInput = apperror.BadRequest(apperror.BadRequestErrorCode, apperror.BadRequestErrorMessage)
+
+// Decode fills query from the URL query string using decoder and body from the
+// JSON request body, either may be nil. Malformed input is reported as
+// ErrMalformedInput, otherwise both are validated and every failing field is
+// reported at once as invalid with the failures as details.
+func Decode(r *http.Request, decoder *schema.Decoder, query, body any, invalid *apperror.Error) error {
+	if query != nil {
+		if err := decoder.Decode(query, r.URL.Query()); err != nil {
+			return queryError(err)
+		}
+	}
+
+	if body != nil {
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			return ErrMalformedInput.WithDetails(map[string]string{"body": "must be a valid JSON object"})
+		}
+	}
+
+	failures := make(map[string]string)
+	for _, params := range []any{query, body} {
+		if params == nil {
+			continue
+		}
+
+		for field, msg := range validate.Struct(params) {
+			failures[field] = msg
+		}
+	}
+
+	if len(failures) > 0 {
+		return invalid.WithDetails(failures)
+	}
+
+	return nil
+}
+
+func queryError(err error) error {
+	details := make(map[string]string)
+
+	var multi schema.MultiError
+	if errors.As(err, &multi) {
+		for field, fieldErr := range multi {
+			var empty schema.EmptyFieldError
+			if errors.As(fieldErr, &empty) {
+				details[field] = "is required"
+				continue
+			}
+			details[field] = "is invalid"
+		}
+	}
+
+	return ErrMalformedInput.WithDetails(details)
+}
@@ -1,6 +1,8 @@
 package nats
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"gogogo/config"
@@ -10,6 +12,7 @@ import (
 
 type Broker struct {
 	Conn *nats.Conn
+	JS   nats.JetStreamContext
 }
 
 func New(cfg *config.Config) (Broker, error) {
@@ -18,5 +21,103 @@ func New(cfg *config.Config) (Broker, error) {
 		return Broker{}, fmt.Errorf("nats connect: %w", err)
 	}
 
-	return Broker{Conn: nc}, nil
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return Broker{}, fmt.Errorf("nats jetstream: %w", err)
+	}
+
+	broker := Broker{Conn: nc, JS: js}
+	if err := broker.EnsureStream(cfg); err != nil {
+		nc.Close()
+		return Broker{}, err
+	}
+
+	return broker, nil
+}
+
+// EnsureStream creates the stream capturing the configured subjects, or
+// brings an existing one in line with the configured retention.
+func (b Broker) EnsureStream(cfg *config.Config) error {
+	storage := nats.FileStorage
+	if cfg.NatsStreamStorage == "memory" {
+		storage = nats.MemoryStorage
+	}
+
+	streamCfg := &nats.StreamConfig{
+		Name:       cfg.NatsStream,
+		Subjects:   cfg.NatsStreamSubjects,
+		Retention:  nats.LimitsPolicy,
+		MaxAge:     cfg.NatsStreamMaxAge,
+		MaxBytes:   cfg.NatsStreamMaxBytes,
+		Storage:    storage,
+		Replicas:   cfg.NatsStreamReplicas,
+		Duplicates: cfg.NatsStreamDuplicates,
+	}
+
+	_, err := b.JS.StreamInfo(streamCfg.Name)
+	switch {
+	case errors.Is(err, nats.ErrStreamNotFound):
+		_, err = b.JS.AddStream(streamCfg)
+	case err == nil:
+		_, err = b.JS.UpdateStream(streamCfg)
+	}
+	if err != nil {
+		return fmt.Errorf("nats stream %s: %w", streamCfg.Name, err)
+	}
+
+	return nil
+}
+
+// EnsureConsumer creates the durable pull consumer ingesting the stream, or
+// brings an existing one in line with the configuration. Being durable, the
+// consumer remembers its position, so messages stored while nothing was
+// pulling are delivered later rather than lost.
+func (b Broker) EnsureConsumer(cfg *config.Config) error {
+	consumerCfg := &nats.ConsumerConfig{
+		Durable:       cfg.NatsConsumer,
+		AckPolicy:     nats.AckExplicitPolicy,
+		AckWait:       cfg.NatsConsumerAckWait,
+		DeliverPolicy: nats.DeliverAllPolicy,
+	}
+
+	_, err := b.JS.ConsumerInfo(cfg.NatsStream, consumerCfg.Durable)
+	switch {
+	case errors.Is(err, nats.ErrConsumerNotFound):
+		_, err = b.JS.AddConsumer(cfg.NatsStream, consumerCfg)
+	case err == nil:
+		_, err = b.JS.UpdateConsumer(cfg.NatsStream, consumerCfg)
+	}
+	if err != nil {
+		return fmt.Errorf("nats consumer %s: %w", consumerCfg.Durable, err)
+	}
+
+	return nil
+}
+
+// Subscribe binds a pull subscription to the durable consumer of the stream.
+func (b Broker) Subscribe(cfg *config.Config) (*nats.Subscription, error) {
+	if err := b.EnsureConsumer(cfg); err != nil {
+		return nil, err
+	}
+
+	sub, err := b.JS.PullSubscribe("", cfg.NatsConsumer, nats.Bind(cfg.NatsStream, cfg.NatsConsumer))
+	if err != nil {
+		return nil, fmt.Errorf("nats subscribe %s: %w", cfg.NatsConsumer, err)
+	}
+
+	return sub, nil
+}
+
+// Publish stores data in the stream bound to subject and waits for the
+// acknowledgement. Messages published again with the same msgId within the
+// stream's duplicate window are stored only once.
+func (b Broker) Publish(ctx context.Context, subject string, data []byte, msgId string) error {
+	opts := []nats.PubOpt{nats.Context(ctx)}
+	if msgId != "" {
+		opts = append(opts, nats.MsgId(msgId))
+	}
+
+	_, err := b.JS.Publish(subject, data, opts...)
+	return err
 }
@@ -0,0 +1,22 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned by Get when the key is absent or expired.
+var ErrMiss = errors.New("cache: miss")
+
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores value under key, a zero ttl keeps the value until it is evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Add stores value under key only if the key is absent and reports whether
+	// it did.
+	Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+	Delete(ctx context.Context, keys ...string) error
+	// Incr atomically increments the integer stored under key, starting from 0.
+	Incr(ctx context.Context, key string) (int64, error)
+}
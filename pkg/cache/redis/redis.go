@@ -1,25 +1,119 @@
 package redis
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"gogogo/config"
+	"gogogo/pkg/cache"
 
 	"github.com/gomodule/redigo/redis"
 )
 
+// healthCheckPeriod is how long an idle connection is trusted before it is
+// pinged on borrow.
+const healthCheckPeriod = time.Minute
+
 type Cache struct {
 	Pool *redis.Pool
 }
 
+var _ cache.Cache = Cache{}
+
 func New(cfg *config.Config) Cache {
+	options := []redis.DialOption{
+		redis.DialDatabase(cfg.RedisDB),
+		redis.DialConnectTimeout(cfg.RedisDialTimeout),
+		redis.DialReadTimeout(cfg.RedisReadTimeout),
+		redis.DialWriteTimeout(cfg.RedisWriteTimeout),
+	}
+	if cfg.RedisPassword != "" {
+		options = append(options, redis.DialPassword(cfg.RedisPassword))
+	}
+
 	pool := &redis.Pool{
-		MaxIdle:     10,
-		IdleTimeout: 240 * time.Second,
-		Dial: func() (redis.Conn, error) {
-			return redis.Dial("tcp", cfg.RedisURL)
+		MaxIdle:     cfg.RedisMaxIdle,
+		MaxActive:   cfg.RedisMaxActive,
+		Wait:        cfg.RedisWait,
+		IdleTimeout: cfg.RedisIdleTimeout,
+		DialContext: func(ctx context.Context) (redis.Conn, error) {
+			return redis.DialContext(ctx, "tcp", cfg.RedisURL, options...)
+		},
+		TestOnBorrow: func(c redis.Conn, lastUsed time.Time) error {
+			if time.Since(lastUsed) < healthCheckPeriod {
+				return nil
+			}
+
+			_, err := c.Do("PING")
+			return err
 		},
 	}
 
 	return Cache{Pool: pool}
 }
+
+// Ping borrows a connection from the pool and checks the server responds.
+func (c Cache) Ping(ctx context.Context) error {
+	_, err := c.do(ctx, "PING")
+	return err
+}
+
+// do runs a single command on a connection borrowed from the pool for the
+// duration of the call.
+func (c Cache) do(ctx context.Context, command string, args ...any) (any, error) {
+	conn, err := c.Pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return redis.DoContext(conn, ctx, command, args...)
+}
+
+func (c Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := redis.Bytes(c.do(ctx, "GET", key))
+	if errors.Is(err, redis.ErrNil) {
+		return nil, cache.ErrMiss
+	}
+
+	return value, err
+}
+
+func (c Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	var err error
+	if ttl > 0 {
+		_, err = c.do(ctx, "SET", key, value, "PX", ttl.Milliseconds())
+	} else {
+		_, err = c.do(ctx, "SET", key, value)
+	}
+
+	return err
+}
+
+func (c Cache) Add(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	args := redis.Args{key, value, "NX"}
+	if ttl > 0 {
+		args = args.Add("PX", ttl.Milliseconds())
+	}
+
+	_, err := redis.String(c.do(ctx, "SET", args...))
+	if errors.Is(err, redis.ErrNil) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (c Cache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := c.do(ctx, "DEL", redis.Args{}.AddFlat(keys)...)
+	return err
+}
+
+func (c Cache) Incr(ctx context.Context, key string) (int64, error) {
+	return redis.Int64(c.do(ctx, "INCR", key))
+}
@@ -0,0 +1,196 @@
+// Package validate checks struct fields against rules declared in `validate`
+// tags, e.g.
+//
+//	Name string `json:"name" validate:"required,max=255"`
+//
+// Supported rules are required, min=N and max=N (value for numbers, length in
+// characters for strings) and oneof=a b c. Every failing field is reported,
+// keyed by its json or schema name. Fields implementing Optional are only
+// checked when provided, required then means they must not be null.
+package validate
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Optional is implemented by wrappers of values that may be left out, such as
+// the fields of a merge patch. A provided nil value stands for an explicit null.
+type Optional interface {
+	Optional() (value any, provided bool)
+}
+
+// Struct validates v, which must be a struct or a pointer to one, and returns
+// a message per failing field. The result is empty when v is valid.
+func Struct(v any) map[string]string {
+	failures := make(map[string]string)
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() == reflect.Struct {
+		validateStruct(value, failures)
+	}
+
+	return failures
+}
+
+func validateStruct(value reflect.Value, failures map[string]string) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if field.Anonymous && reflect.Indirect(value.Field(i)).Kind() == reflect.Struct {
+			validateStruct(reflect.Indirect(value.Field(i)), failures)
+			continue
+		}
+
+		rules, ok := field.Tag.Lookup("validate")
+		if !ok || rules == "" {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if optional, ok := fieldValue.Interface().(Optional); ok {
+			wrapped, provided := optional.Optional()
+			if !provided {
+				continue
+			}
+
+			if wrapped == nil {
+				if hasRule(rules, "required") {
+					failures[fieldName(field)] = "must not be null"
+				}
+				continue
+			}
+
+			fieldValue = reflect.ValueOf(wrapped)
+			if rules = withoutRule(rules, "required"); rules == "" {
+				continue
+			}
+		}
+
+		if msg := check(fieldValue, rules); msg != "" {
+			failures[fieldName(field)] = msg
+		}
+	}
+}
+
+// check applies the rules to value and returns the first failure message.
+func check(value reflect.Value, rules string) string {
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "required" {
+			if value.IsZero() {
+				return "is required"
+			}
+			continue
+		}
+
+		// the remaining rules apply to values that were provided
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		}
+
+		switch name {
+		case "min", "max":
+			limit, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				panic(fmt.Sprintf("validate: bad %s rule %q", name, rule))
+			}
+			if msg := checkBound(value, name, limit); msg != "" {
+				return msg
+			}
+		case "oneof":
+			options := strings.Fields(arg)
+			if value.Kind() == reflect.String && value.Len() > 0 && !contains(options, value.String()) {
+				return "must be one of: " + strings.Join(options, ", ")
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q", rule))
+		}
+	}
+
+	return ""
+}
+
+func checkBound(value reflect.Value, rule string, limit int64) string {
+	switch value.Kind() {
+	case reflect.String:
+		length := int64(utf8.RuneCountInString(value.String()))
+		if rule == "min" && length < limit {
+			return fmt.Sprintf("must be at least %d characters", limit)
+		}
+		if rule == "max" && length > limit {
+			return fmt.Sprintf("must be at most %d characters", limit)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rule == "min" && value.Int() < limit {
+			return fmt.Sprintf("must be at least %d", limit)
+		}
+		if rule == "max" && value.Int() > limit {
+			return fmt.Sprintf("must be at most %d", limit)
+		}
+	case reflect.Slice, reflect.Map:
+		if rule == "min" && int64(value.Len()) < limit {
+			return fmt.Sprintf("must contain at least %d items", limit)
+		}
+		if rule == "max" && int64(value.Len()) > limit {
+			return fmt.Sprintf("must contain at most %d items", limit)
+		}
+	}
+
+	return ""
+}
+
+func hasRule(rules, name string) bool {
+	for _, rule := range strings.Split(rules, ",") {
+		if rule == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func withoutRule(rules, name string) string {
+	var kept []string
+	for _, rule := range strings.Split(rules, ",") {
+		if rule != name {
+			kept = append(kept, rule)
+		}
+	}
+
+	return strings.Join(kept, ",")
+}
+
+func contains(options []string, value string) bool {
+	for _, option := range options {
+		if option == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fieldName returns the name clients know the field by.
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "schema"} {
+		name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	r, size := utf8.DecodeRuneInString(field.Name)
+	return string(unicode.ToLower(r)) + field.Name[size:]
+}